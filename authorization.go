@@ -1,5 +1,10 @@
 package eve
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Authorizable interface {
 	AuthorizedRead(user *User) bool
 	AuthorizedWrite(user *User) bool
@@ -7,25 +12,153 @@ type Authorizable interface {
 	BindAuthorization(auth Authorization)
 }
 
-func (auth *Authorization) DefaultAuthorizedRead(user *User) bool {
-	if user.Id == AGENT_USER {
-		return true
+// Normalize returns the bit flags of a legacy combined mode, or the mode itself
+func (mode PolicyMode) Normalize() PolicyMode {
+	if flags, ok := LegacyPolicyModes[mode]; ok {
+		return flags
 	}
-	return (auth.Owner == user.Id)
+	return mode
 }
 
-func (auth *Authorization) DefaultAuthorizedWrite(user *User) bool {
-	if user.Id == AGENT_USER {
+// Allows reports whether the policy mode grants the requested permission bits
+func (mode PolicyMode) Allows(perm PolicyMode) bool {
+	mode = mode.Normalize()
+	if mode&POLICY_ALL != 0 {
 		return true
 	}
-	return (auth.Owner == user.Id)
+	return perm != POLICY_NONE && mode&perm == perm
+}
+
+var policyNames = []struct {
+	mode PolicyMode
+	name string
+}{
+	{POLICY_ALL, "all"},
+	{POLICY_READ, "read"},
+	{POLICY_WRITE, "write"},
+	{POLICY_EXECUTE, "execute"},
+}
+
+// ParsePolicyMode parses comma separated permission names, e.g. "read,execute", into PolicyMode
+func ParsePolicyMode(s string) (PolicyMode, error) {
+	mode := POLICY_NONE
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" || part == "none" {
+			continue
+		}
+		found := false
+		for _, p := range policyNames {
+			if p.name == part {
+				mode |= p.mode
+				found = true
+				break
+			}
+		}
+		if !found {
+			return POLICY_NONE, fmt.Errorf("Invalid policy mode %q. Valid values are none, all, read, write and execute", part)
+		}
+	}
+	return mode, nil
 }
 
-func (auth *Authorization) DefaultAuthorizedExecute(user *User) bool {
-	if user.Id == AGENT_USER {
-		return true
+func (mode PolicyMode) String() string {
+	if mode == POLICY_NONE {
+		return "none"
+	}
+	mode = mode.Normalize()
+	var names []string
+	for _, p := range policyNames {
+		if mode&p.mode != 0 {
+			names = append(names, p.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Groups returns every group the user belongs to: the user itself, its organization, its teams and public
+func (user *User) Groups() []Group {
+	groups := []Group{Group(user.Id)}
+	if user.Organization != "" {
+		groups = append(groups, Group(user.Organization))
+	}
+	for _, team := range user.Teams {
+		groups = append(groups, Group(team))
+	}
+	return append(groups, PUBLIC_GROUP)
+}
+
+// IsAdmin reports whether the user is eve's system user or an eve admin user
+func (user *User) IsAdmin() bool {
+	return user != nil && (user.Id == AGENT_USER || user.Admin)
+}
+
+// ForbiddenError is returned when user isn't authorized to perform an operation on a resource
+type ForbiddenError struct {
+	User   UserId
+	Action string // operation denied to the user, e.g. "modify infrastructure dev"
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("User %s is not authorized to %s", e.User, e.Action)
+}
+
+// PolicyFor evaluates the permission granted to the user through ownership and every group the user belongs to
+func (auth *Authorization) PolicyFor(user *User) PolicyMode {
+	if user == nil {
+		return POLICY_NONE
+	}
+	if user.IsAdmin() || auth.Owner == user.Id {
+		return POLICY_ALL
 	}
-	return (auth.Owner == user.Id)
+	mode := POLICY_NONE
+	for _, group := range user.Groups() {
+		mode |= auth.GroupAccess[group].Normalize()
+	}
+	return mode
+}
+
+// GrantAccess grants mode to the group, replacing the group's previous mode
+func (auth *Authorization) GrantAccess(group Group, mode PolicyMode) {
+	if auth.GroupAccess == nil {
+		auth.GroupAccess = make(map[Group]PolicyMode)
+	}
+	auth.GroupAccess[group] = mode
+}
+
+// TransferOwnership replaces the owner and moves the owner's group access
+func (auth *Authorization) TransferOwnership(from UserId, to UserId) {
+	if auth.GroupAccess == nil {
+		auth.GroupAccess = make(map[Group]PolicyMode)
+	}
+	delete(auth.GroupAccess, Group(from))
+	auth.Owner = to
+	auth.GroupAccess[Group(to)] = POLICY_ALL
+}
+
+// AuthorizedShare reports whether the user can change the resource's group access
+func (auth *Authorization) AuthorizedShare(user *User) bool {
+	if user == nil {
+		return false
+	}
+	return user.IsAdmin() || auth.Owner == user.Id
+}
+
+// AuthorizedTransfer reports whether the user can transfer the resource's ownership
+func (auth *Authorization) AuthorizedTransfer(user *User) bool {
+	return user.IsAdmin()
+}
+
+func (auth *Authorization) DefaultAuthorizedRead(user *User) bool {
+	return auth.PolicyFor(user).Allows(POLICY_READ)
+}
+
+func (auth *Authorization) DefaultAuthorizedWrite(user *User) bool {
+	return auth.PolicyFor(user).Allows(POLICY_WRITE)
+}
+
+func (auth *Authorization) DefaultAuthorizedExecute(user *User) bool {
+	return auth.PolicyFor(user).Allows(POLICY_EXECUTE)
 }
 
 func (infra *Infrastructure) AuthorizedRead(user *User) bool {
@@ -77,15 +210,15 @@ func (quoinArchive *QuoinArchive) BindAuthorization(auth Authorization) {
 }
 
 func (provider *Provider) AuthorizedRead(user *User) bool {
-	return true
+	return provider.Authorization.DefaultAuthorizedRead(user)
 }
 
 func (provider *Provider) AuthorizedWrite(user *User) bool {
-	return true
+	return provider.Authorization.DefaultAuthorizedWrite(user)
 }
 
 func (provider *Provider) AuthorizedExecute(user *User) bool {
-	return true
+	return provider.Authorization.DefaultAuthorizedExecute(user)
 }
 
 func (provider *Provider) BindAuthorization(auth Authorization) {
@@ -0,0 +1,171 @@
+package eve_test
+
+import (
+	"testing"
+
+	"github.com/concur/eve"
+)
+
+func TestPolicyMode_Allows(t *testing.T) {
+	cases := []struct {
+		mode    eve.PolicyMode
+		perm    eve.PolicyMode
+		allowed bool
+	}{
+		{eve.POLICY_NONE, eve.POLICY_READ, false},
+		{eve.POLICY_ALL, eve.POLICY_WRITE, true},
+		{eve.POLICY_READ, eve.POLICY_READ, true},
+		{eve.POLICY_READ, eve.POLICY_WRITE, false},
+		{eve.POLICY_READ_EXECUTE, eve.POLICY_EXECUTE, true},
+		{eve.POLICY_READ_EXECUTE, eve.POLICY_READ_WRITE, false},
+		{eve.POLICY_READ | eve.POLICY_WRITE, eve.POLICY_READ_WRITE, true},
+		{eve.POLICY_READ, eve.POLICY_NONE, false},
+	}
+	for _, c := range cases {
+		if allowed := c.mode.Allows(c.perm); allowed != c.allowed {
+			t.Errorf("PolicyMode %v allows %v should be %v, got %v", c.mode, c.perm, c.allowed, allowed)
+		}
+	}
+}
+
+func TestAuthorization_DefaultAuthorized(t *testing.T) {
+	auth := &eve.Authorization{
+		Owner: eve.UserId("alice"),
+		GroupAccess: map[eve.Group]eve.PolicyMode{
+			eve.Group("alice"):  eve.POLICY_ALL,
+			eve.Group("concur"): eve.POLICY_READ,
+			eve.Group("sre"):    eve.POLICY_WRITE_EXECUTE,
+			eve.PUBLIC_GROUP:    eve.POLICY_NONE,
+		},
+	}
+
+	owner := &eve.User{Id: "alice", Organization: "concur"}
+	member := &eve.User{Id: "bob", Organization: "concur"}
+	teammate := &eve.User{Id: "carol", Organization: "concur", Teams: []eve.Team{"sre"}}
+	outsider := &eve.User{Id: "dave", Organization: "acme", Teams: []eve.Team{"dave"}}
+	agent := &eve.User{Id: eve.AGENT_USER}
+
+	cases := []struct {
+		user                 *eve.User
+		read, write, execute bool
+	}{
+		{owner, true, true, true},
+		{member, true, false, false},
+		{teammate, true, true, true},
+		{outsider, false, false, false},
+		{agent, true, true, true},
+		{nil, false, false, false},
+	}
+	for _, c := range cases {
+		if got := auth.DefaultAuthorizedRead(c.user); got != c.read {
+			t.Errorf("Unexpected read permission for %#v: %v", c.user, got)
+		}
+		if got := auth.DefaultAuthorizedWrite(c.user); got != c.write {
+			t.Errorf("Unexpected write permission for %#v: %v", c.user, got)
+		}
+		if got := auth.DefaultAuthorizedExecute(c.user); got != c.execute {
+			t.Errorf("Unexpected execute permission for %#v: %v", c.user, got)
+		}
+	}
+}
+
+func TestAuthorization_PublicGroup(t *testing.T) {
+	auth := &eve.Authorization{
+		Owner: eve.UserId("alice"),
+		GroupAccess: map[eve.Group]eve.PolicyMode{
+			eve.PUBLIC_GROUP: eve.POLICY_READ,
+		},
+	}
+	user := &eve.User{Id: "dave", Organization: "acme"}
+	if !auth.DefaultAuthorizedRead(user) {
+		t.Errorf("Public read access should be granted to every user")
+	}
+	if auth.DefaultAuthorizedWrite(user) {
+		t.Errorf("Public read access should not grant write permission")
+	}
+}
+
+func TestAuthorization_Admin(t *testing.T) {
+	auth := &eve.Authorization{
+		Owner:       eve.UserId("alice"),
+		GroupAccess: map[eve.Group]eve.PolicyMode{},
+	}
+	admin := &eve.User{Id: "root", Organization: "concur", Admin: true}
+	owner := &eve.User{Id: "alice", Organization: "concur"}
+
+	if !auth.DefaultAuthorizedWrite(admin) || !auth.DefaultAuthorizedExecute(admin) {
+		t.Errorf("Admin user should have full permission on every resource")
+	}
+	if !auth.AuthorizedTransfer(admin) {
+		t.Errorf("Admin user should be able to transfer ownership")
+	}
+	if auth.AuthorizedTransfer(owner) {
+		t.Errorf("Owner should not be able to transfer ownership without admin role")
+	}
+	if !auth.AuthorizedShare(owner) {
+		t.Errorf("Owner should be able to change group access")
+	}
+}
+
+func TestProvider_Authorized(t *testing.T) {
+	provider := &eve.Provider{
+		Name: "aws",
+		Authorization: eve.Authorization{
+			Owner: eve.UserId("alice"),
+			GroupAccess: map[eve.Group]eve.PolicyMode{
+				eve.Group("concur"): eve.POLICY_READ,
+				eve.Group("sre"):    eve.POLICY_READ_EXECUTE,
+			},
+		},
+	}
+	member := &eve.User{Id: "bob", Organization: "concur"}
+	teammate := &eve.User{Id: "carol", Organization: "concur", Teams: []eve.Team{"sre"}}
+	outsider := &eve.User{Id: "dave", Organization: "acme"}
+
+	if !provider.AuthorizedRead(member) || provider.AuthorizedExecute(member) {
+		t.Errorf("Organization member should only read provider")
+	}
+	if !provider.AuthorizedExecute(teammate) {
+		t.Errorf("Team member should be able to use provider")
+	}
+	if provider.AuthorizedRead(outsider) {
+		t.Errorf("Provider should not be readable without granted access")
+	}
+}
+
+func TestPolicyMode_legacyCombinedModes(t *testing.T) {
+	auth := eve.Authorization{
+		Owner: "alice",
+		GroupAccess: map[eve.Group]eve.PolicyMode{
+			eve.Group("sre"):  eve.PolicyMode(32),
+			eve.Group("team"): eve.PolicyMode(128),
+		},
+	}
+	sre := &eve.User{Id: "bob", Teams: []eve.Team{"sre"}}
+	if !auth.DefaultAuthorizedRead(sre) || !auth.DefaultAuthorizedWrite(sre) || auth.DefaultAuthorizedExecute(sre) {
+		t.Errorf("Legacy read-write mode should grant read and write only")
+	}
+	team := &eve.User{Id: "carol", Teams: []eve.Team{"team"}}
+	if auth.DefaultAuthorizedRead(team) || !auth.DefaultAuthorizedWrite(team) || !auth.DefaultAuthorizedExecute(team) {
+		t.Errorf("Legacy write-execute mode should grant write and execute only")
+	}
+	if mode := eve.PolicyMode(64).String(); mode != "read,execute" {
+		t.Errorf("Legacy read-execute mode should print read,execute, got %s", mode)
+	}
+}
+
+func TestAuthorization_TransferOwnership(t *testing.T) {
+	var auth eve.Authorization
+	auth.GrantAccess(eve.Group("sre"), eve.POLICY_READ)
+	auth.TransferOwnership("", "alice")
+	auth.TransferOwnership("alice", "bob")
+	if auth.Owner != "bob" || auth.GroupAccess[eve.Group("bob")] != eve.POLICY_ALL {
+		t.Errorf("New owner should have full access, got %#v", auth)
+	}
+	if _, ok := auth.GroupAccess[eve.Group("alice")]; ok {
+		t.Errorf("Previous owner's access should be moved, got %#v", auth.GroupAccess)
+	}
+	if auth.GroupAccess[eve.Group("sre")] != eve.POLICY_READ {
+		t.Errorf("Granted group access should be kept, got %#v", auth.GroupAccess)
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// UpdateAccess grants the group policy (e.g. "read,execute") on a quoin, an infrastructure or a provider
+func (c *Client) UpdateAccess(resource, name, group, policy string) error {
+	body, err := json.Marshal(map[string]string{"policy": policy})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("/%s/%s/access/%s", resource, name, group)
+	input := &RequestInput{
+		Params:     make(map[string]string),
+		Headers:    make(map[string]string),
+		Body:       bytes.NewReader(body),
+		BodyLength: int64(len(body)),
+	}
+	req, err := c.Request("PUT", endpoint, input)
+	if err != nil {
+		return fmt.Errorf("UpdateAccess: %s", err)
+	}
+
+	if _, err := checkResponse(c.HttpClient.Do(req)); err != nil {
+		return fmt.Errorf("UpdateAccess: %s", err)
+	}
+	return nil
+}
+
+// DeleteAccess revokes the group's access on a quoin, an infrastructure or a provider
+func (c *Client) DeleteAccess(resource, name, group string) error {
+	endpoint := fmt.Sprintf("/%s/%s/access/%s", resource, name, group)
+	input := &RequestInput{
+		Params:     make(map[string]string),
+		Headers:    make(map[string]string),
+		Body:       nil,
+		BodyLength: 0,
+	}
+	req, err := c.Request("DELETE", endpoint, input)
+	if err != nil {
+		return fmt.Errorf("DeleteAccess: %s", err)
+	}
+
+	if _, err := checkResponse(c.HttpClient.Do(req)); err != nil {
+		return fmt.Errorf("DeleteAccess: %s", err)
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -29,6 +30,9 @@ const (
 // ErrorAuth is the error returned if a 401 is returned by an API request.
 var ErrorAuth = fmt.Errorf("authentication failed")
 
+// ErrorForbidden is the error returned if a 403 is returned by an API request.
+var ErrorForbidden = fmt.Errorf("access forbidden")
+
 // ErrorNotFound is the error returned if a 404 is returned by an API request.
 var ErrorNotFound = fmt.Errorf("resource not found")
 
@@ -146,6 +150,15 @@ type RequestInput struct {
 func (c *Client) Request(verb, resourcePath string, input *RequestInput) (*http.Request, error) {
 	u := *c.Url
 	u.Path = path.Join(c.Url.Path, resourcePath)
+	if input != nil && len(input.Params) > 0 {
+		query := u.Query()
+		for k, v := range input.Params {
+			if v != "" {
+				query.Set(k, v)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
 	return c.rawRequest(verb, &u, input)
 }
 
@@ -210,13 +223,18 @@ func checkResponse(resp *http.Response, err error) (*http.Response, error) {
 	}
 
 	switch resp.StatusCode {
-	case 200:
+	case 200, 201, 202:
 		return resp, nil
 	case 401:
 		return nil, ErrorAuth
+	case 403:
+		return nil, ErrorForbidden
 	case 404:
 		return nil, ErrorNotFound
 	default:
+		if msg := strings.TrimSpace(buf.String()); msg != "" {
+			return nil, fmt.Errorf("Client: %s: %s", resp.Status, msg)
+		}
 		return nil, fmt.Errorf("Client: %s", resp.Status)
 	}
 }
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/concur/eve"
+)
+
+// ListQuoins retrieves the page of quoins after cursor which match options. Empty cursor retrieves the first page
+func (c *Client) ListQuoins(options eve.ListOptions, cursor string) (*eve.QuoinList, error) {
+	var quoins eve.QuoinList
+	if err := c.list("/quoin", options, cursor, &quoins); err != nil {
+		return nil, fmt.Errorf("ListQuoins: %s", err)
+	}
+	return &quoins, nil
+}
+
+// ListInfrastructures retrieves the page of infrastructures after cursor which match options. Empty cursor retrieves the first page
+func (c *Client) ListInfrastructures(options eve.ListOptions, cursor string) (*eve.InfrastructureList, error) {
+	var infras eve.InfrastructureList
+	if err := c.list("/infrastructure", options, cursor, &infras); err != nil {
+		return nil, fmt.Errorf("ListInfrastructures: %s", err)
+	}
+	return &infras, nil
+}
+
+func (c *Client) list(endpoint string, options eve.ListOptions, cursor string, out interface{}) error {
+	params := map[string]string{
+		"owner":    string(options.Owner),
+		"quoin":    options.Quoin,
+		"provider": options.ProviderSlug,
+		"selector": options.Selector.String(),
+		"cursor":   cursor,
+	}
+	if options.Status != nil {
+		params["status"] = options.Status.String()
+	}
+	if options.Limit > 0 {
+		params["limit"] = strconv.Itoa(options.Limit)
+	}
+	input := &RequestInput{
+		Params:     params,
+		Headers:    make(map[string]string),
+		Body:       nil,
+		BodyLength: 0,
+	}
+	req, err := c.Request("GET", endpoint, input)
+	if err != nil {
+		return err
+	}
+
+	resp, err := checkResponse(c.HttpClient.Do(req))
+	if err != nil {
+		return err
+	}
+	return decodeJson(resp, out)
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/concur/eve"
+)
+
+// GetInfrastructureOutputs retrieves outputs of the infrastructure's state keyed by name
+func (c *Client) GetInfrastructureOutputs(name string) (map[string]eve.StateOutput, error) {
+	var outputs map[string]eve.StateOutput
+	if err := c.get(fmt.Sprintf("/infrastructure/%s/outputs", name), &outputs); err != nil {
+		return nil, fmt.Errorf("GetInfrastructureOutputs: %s", err)
+	}
+	return outputs, nil
+}
+
+// GetInfrastructureOutput retrieves the output key of the infrastructure's state
+func (c *Client) GetInfrastructureOutput(name, key string) (*eve.StateOutput, error) {
+	var output eve.StateOutput
+	if err := c.get(fmt.Sprintf("/infrastructure/%s/outputs/%s", name, key), &output); err != nil {
+		return nil, fmt.Errorf("GetInfrastructureOutput: %s", err)
+	}
+	return &output, nil
+}
+
+func (c *Client) get(endpoint string, out interface{}) error {
+	input := &RequestInput{
+		Params:     make(map[string]string),
+		Headers:    make(map[string]string),
+		Body:       nil,
+		BodyLength: 0,
+	}
+	req, err := c.Request("GET", endpoint, input)
+	if err != nil {
+		return err
+	}
+
+	resp, err := checkResponse(c.HttpClient.Do(req))
+	if err != nil {
+		return err
+	}
+	return decodeJson(resp, out)
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 
 	log "github.com/Sirupsen/logrus"
@@ -33,3 +35,80 @@ func (c *Client) GetProvider(name string) *eve.Provider {
 
 	return provider
 }
+
+// GetProviders retrieves the list of providers
+func (c *Client) GetProviders() ([]eve.Provider, error) {
+	input := &RequestInput{
+		Params:     make(map[string]string),
+		Headers:    make(map[string]string),
+		Body:       nil,
+		BodyLength: 0,
+	}
+	req, err := c.Request("GET", "/provider", input)
+	if err != nil {
+		return nil, fmt.Errorf("GetProviders: %s", err)
+	}
+
+	resp, err := checkResponse(c.HttpClient.Do(req))
+	if err != nil {
+		return nil, fmt.Errorf("GetProviders: %s", err)
+	}
+
+	var providers []eve.Provider
+	if err := decodeJson(resp, &providers); err != nil {
+		return nil, fmt.Errorf("GetProviders: %s", err)
+	}
+	return providers, nil
+}
+
+// CreateProvider creates a new provider
+func (c *Client) CreateProvider(provider *eve.Provider) error {
+	return c.sendProvider("POST", "/provider", provider)
+}
+
+// UpdateProvider replaces schema of the provider with the given name
+func (c *Client) UpdateProvider(name string, provider *eve.Provider) error {
+	return c.sendProvider("PUT", fmt.Sprintf("/provider/%s", name), provider)
+}
+
+// DeleteProvider deletes the provider with the given name
+func (c *Client) DeleteProvider(name string) error {
+	endpoint := fmt.Sprintf("/provider/%s", name)
+	input := &RequestInput{
+		Params:     make(map[string]string),
+		Headers:    make(map[string]string),
+		Body:       nil,
+		BodyLength: 0,
+	}
+	req, err := c.Request("DELETE", endpoint, input)
+	if err != nil {
+		return fmt.Errorf("DeleteProvider: %s", err)
+	}
+
+	if _, err := checkResponse(c.HttpClient.Do(req)); err != nil {
+		return fmt.Errorf("DeleteProvider: %s", err)
+	}
+	return nil
+}
+
+func (c *Client) sendProvider(verb, endpoint string, provider *eve.Provider) error {
+	body, err := json.Marshal(provider)
+	if err != nil {
+		return err
+	}
+	input := &RequestInput{
+		Params:     make(map[string]string),
+		Headers:    make(map[string]string),
+		Body:       bytes.NewReader(body),
+		BodyLength: int64(len(body)),
+	}
+	req, err := c.Request(verb, endpoint, input)
+	if err != nil {
+		return fmt.Errorf("%s %s: %s", verb, endpoint, err)
+	}
+
+	if _, err := checkResponse(c.HttpClient.Do(req)); err != nil {
+		return fmt.Errorf("%s %s: %s", verb, endpoint, err)
+	}
+	return nil
+}
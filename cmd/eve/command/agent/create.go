@@ -36,15 +36,17 @@ func create(infraSvc eve.InfrastructureService, stateServer *http.ApiServer) eve
 	}
 	return func(infra *eve.Infrastructure) {
 		if infra == nil {
-			err := errors.New("Empty infrastructure object detected")
-			writeError(infra.Name, err)
-			log.Println(err)
+			log.Println(errors.New("Empty infrastructure object detected"))
 			return
 		}
 		log.Printf("Start infrastructure creation process for %s.\n", infra.Name)
 		if err := infraSvc.UpdateInfrastructureStatus(infra.Name, eve.RUNNING); err != nil {
-			writeError(infra.Name, err)
+			// Infrastructure is handled by another agent or isn't in a startable status, leave its record untouched
+			if _, ok := err.(*eve.TransitionError); !ok {
+				writeError(infra.Name, err)
+			}
 			log.Println(err)
+			return
 		}
 		quoinSvc := service.NewQuoinService(getAgentUser())
 		id := quoinSvc.GetQuoinArchiveIdFromUri(infra.Quoin.ArchiveUri)
@@ -63,8 +65,13 @@ func create(infraSvc eve.InfrastructureService, stateServer *http.ApiServer) eve
 		log.Println("Infrastructure", infra.Name, "gets Quoin Archive:", id, quoinArchive.QuoinName)
 		varfile := createVarFile(infra.Variables)
 		remoteState := stateEndpoint(stateServer, infra.Name)
-		authenticator := createAuthenticator(infra.ProviderSlug)
-		tf := terraform.NewTerraformWithAuthenticator(infra.Name, remoteState, quoinArchive.Modules, varfile, authenticator)
+		credentials, err := createCredentials(infra)
+		if err != nil {
+			writeError(infra.Name, err)
+			log.Println(err)
+			return
+		}
+		tf := terraform.NewTerraformWithCredentials(infra.Name, remoteState, quoinArchive.Modules, varfile, credentials)
 		if err := tf.ApplyQuoin(); err != nil {
 			writeError(infra.Name, err)
 			log.Println(err)
@@ -2,14 +2,12 @@ package agent
 
 import (
 	"fmt"
-	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
-	"github.com/concur/eve/client"
 	"github.com/concur/eve/http"
-	"github.com/concur/eve/pkg/vault"
-	"github.com/concur/eve/provider/aws"
+	"github.com/concur/eve/provider"
+	"github.com/concur/eve/service"
 )
 
 func createVarFile(quoinVars []eve.QuoinVar) []byte {
@@ -34,33 +32,12 @@ func getAgentUser() *eve.User {
 	}
 }
 
-func getAccount(providerSlug string) *aws.Account {
-	s := strings.Split(providerSlug, ":")
-	if len(s) == 0 {
-		// TODO(nl): return error
+func createCredentials(infra *eve.Infrastructure) (provider.Credentials, error) {
+	log.Infof("providerSlug: %s", infra.ProviderSlug)
+	options := provider.Options{
+		Region:      infra.Variable(service.REGION_VARIABLE),
+		Role:        infra.Role,
+		SessionName: "quoin",
 	}
-	log.Info("providerSlug: %s", s)
-	c := client.NewDefaultClient()
-	p := c.GetProvider(s[0])
-	a := aws.NewProvider(p)
-
-	return a.GetAccount(s[1])
-}
-
-func getRole() string {
-	key := "secret/quoin/providers/aws/meta"
-	data, err := vault.GetLogicalData(key)
-	if err != nil {
-		// TODO(nl): return error
-	}
-	role := data["role"].(string)
-	return role
-}
-
-func createAuthenticator(providerSlug string) *aws.Authenticator {
-	a := getAccount(providerSlug)
-	i := getRole()
-	sessionName := "quoin"
-	auth := aws.NewAuthenticator(a, i, sessionName)
-	return auth
+	return service.NewProviderService(getAgentUser()).NewCredentials(infra.ProviderSlug, options)
 }
@@ -25,4 +25,8 @@ func addCommands() {
 	agentCmd.AddCommand(agent.DeleteCmd(apiServer))
 	eveCmd.AddCommand(dbCmd)
 	dbCmd.AddCommand(db.InitCmd)
+	dbCmd.AddCommand(db.MigrateCmd)
+	dbCmd.AddCommand(db.StatusCmd)
+	dbCmd.AddCommand(db.GcCmd)
+	dbCmd.AddCommand(db.RekeyCmd)
 }
@@ -7,5 +7,5 @@ import (
 var dbCmd = &cobra.Command{
 	Use:   "db",
 	Short: "To control eve db",
-	Long:  `db will help to initialize eve's db (rethinkdb)`,
+	Long:  `db will help to initialize eve's db (rethinkdb, or bolt file selected by EVE_STORE)`,
 }
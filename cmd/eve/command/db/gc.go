@@ -0,0 +1,61 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	"github.com/concur/eve/pkg/config"
+	"github.com/concur/eve/service"
+	"github.com/concur/eve/service/boltdb"
+	"github.com/spf13/cobra"
+)
+
+var gcDryRun bool
+
+var GcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "To delete unused quoin archives",
+	Long:  `To delete archives of obsoleted quoins which no infrastructure references`,
+	Run: func(cmd *cobra.Command, args []string) {
+		quoinSvc := service.NewQuoinServiceWithStore(&eve.User{Id: eve.AGENT_USER}, openStore())
+		archives, err := quoinSvc.CollectQuoinArchives(gcDryRun)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "QUOIN\tARCHIVE\tSIZE\tUPLOADED AT")
+		for _, archive := range archives {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", archive.QuoinName, archive.Id, archive.Size, archive.CreatedAt.Format("2006-01-02T15:04:05Z07:00"))
+		}
+		w.Flush()
+		if err != nil {
+			log.Panicln(err)
+		}
+		if gcDryRun {
+			log.Printf("%d archives would be deleted.", len(archives))
+		} else {
+			log.Printf("%d archives are deleted.", len(archives))
+		}
+	},
+}
+
+func init() {
+	GcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "List archives to delete without deleting them.")
+}
+
+// openStore opens the store selected by EVE_STORE
+func openStore() eve.Store {
+	storeConfig := loadStoreConfig()
+	if storeConfig.Type == config.STORE_BOLT {
+		store, err := boltdb.NewStore(storeConfig.Path)
+		if err != nil {
+			log.Panicln(err)
+		}
+		return store
+	}
+	db := connectDB(0)
+	if db == nil {
+		log.Panicln("Failed to connect rethinkdb server")
+	}
+	return db
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve/pkg/config"
+	"github.com/concur/eve/service/boltdb"
 	"github.com/concur/eve/service/rethinkdb"
 	"github.com/spf13/cobra"
 	"time"
@@ -14,9 +16,16 @@ const (
 var InitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "To initialize eve db",
-	Long:  `To initialize eve db`,
+	Long:  `To initialize eve db and apply pending migrations`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Println("Initializing DB...")
+		if storeConfig := loadStoreConfig(); storeConfig.Type == config.STORE_BOLT {
+			if _, err := boltdb.NewStore(storeConfig.Path); err != nil {
+				log.Panicln(err)
+			}
+			log.Println("DB is in good condition.")
+			return
+		}
 		db := connectDB(0)
 		if err := db.Initialization(); err != nil {
 			log.Panicln(err)
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "To apply pending eve db migrations",
+	Long:  `To apply pending eve db migrations in version order and record them in the migrations table`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if loadStoreConfig().Type == config.STORE_BOLT {
+			log.Println("Bolt store has no versioned migrations. Run eve db init to create it.")
+			return
+		}
+		applied, err := connectDB(0).Migrate()
+		for _, migration := range applied {
+			log.Printf("Migration %d is applied.", migration.Version)
+		}
+		if err != nil {
+			log.Panicln(err)
+		}
+		if len(applied) == 0 {
+			log.Println("DB is up to date.")
+		}
+	},
+}
+
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "To show applied and pending eve db migrations",
+	Long:  `To show applied and pending eve db migrations`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if loadStoreConfig().Type == config.STORE_BOLT {
+			log.Println("Bolt store has no versioned migrations.")
+			return
+		}
+		states, err := connectDB(0).MigrationStatus()
+		if err != nil {
+			log.Panicln(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+		for _, state := range states {
+			status, appliedAt := "pending", "-"
+			if state.Applied != nil {
+				status, appliedAt = "applied", state.Applied.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", state.Version, status, appliedAt, state.Description)
+		}
+		w.Flush()
+	},
+}
+
+func loadStoreConfig() *config.StoreConfig {
+	storeConfig, err := config.NewStoreConfig()
+	if err != nil {
+		log.Panicln(err)
+	}
+	return storeConfig
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	"github.com/concur/eve/service"
+	"github.com/spf13/cobra"
+)
+
+var rekeyRotate bool
+
+var RekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "To encrypt infrastructure states with the latest organization keys",
+	Long: `To encrypt every infrastructure state and state version with the latest vault transit key of its organization.
+States stored before EVE_STATE_TRANSIT_MOUNT was set are encrypted as well`,
+	Run: func(cmd *cobra.Command, args []string) {
+		infraSvc := service.NewInfrastructureServiceWithStore(&eve.User{Id: eve.AGENT_USER}, openStore())
+		rekeyed, err := infraSvc.RekeyInfrastructureStates(rekeyRotate)
+		if err != nil {
+			log.Panicf("%d states are rekeyed before failure: %v", rekeyed, err)
+		}
+		log.Printf("%d states are rekeyed.", rekeyed)
+	},
+}
+
+func init() {
+	RekeyCmd.Flags().BoolVar(&rekeyRotate, "rotate", false, "Rotate organization keys before rekeying states.")
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"github.com/concur/eve"
 	"github.com/concur/eve/http"
 	"github.com/concur/eve/http/httprouter"
 	"github.com/concur/eve/http/vault"
@@ -30,6 +31,7 @@ func init() {
 		DNS:    apiConfig.DNS,
 		Scheme: apiConfig.Scheme,
 	}
+	eve.NumericStatusJSON = apiConfig.StatusFormat == config.STATUS_FORMAT_NUMBER
 	if apiConfig.Scheme == "https" {
 		apiServer.CertFile = apiConfig.CertFile // Retrieve from Vault
 		apiServer.KeyFile = apiConfig.KeyFile   // Retrieve from Vault
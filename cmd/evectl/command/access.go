@@ -0,0 +1,70 @@
+package command
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/concur/eve/client"
+	"github.com/spf13/cobra"
+)
+
+// NewAccessCommand creates an instance of the AccessCommand
+func NewAccessCommand(out, err io.Writer) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "access <grant|revoke>",
+		Short: "Share quoins, infrastructures and providers with other groups",
+		Long:  `Used for granting and revoking group access on quoins, infrastructures and providers`,
+	}
+
+	command.AddCommand(&cobra.Command{
+		Use:   "grant <quoin|infrastructure|provider> <name> <group> <policy>",
+		Short: "Grant group access on a resource",
+		Long:  `Grant a user, team or organization the comma separated policy (none, all, read, write, execute) on a quoin, an infrastructure or a provider`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 4 {
+				return fmt.Errorf("grant requires <quoin|infrastructure|provider> <name> <group> <policy> arguments")
+			}
+			resource, e := resourcePath(args[0])
+			if e != nil {
+				return e
+			}
+			if e := client.NewDefaultClient().UpdateAccess(resource, args[1], args[2], args[3]); e != nil {
+				return e
+			}
+			fmt.Fprintf(out, "Group %s is granted %s access on %s %s\n", args[2], args[3], args[0], args[1])
+			return nil
+		},
+	})
+
+	command.AddCommand(&cobra.Command{
+		Use:   "revoke <quoin|infrastructure|provider> <name> <group>",
+		Short: "Revoke group access on a resource",
+		Long:  `Revoke a user, team or organization's access on a quoin, an infrastructure or a provider`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 3 {
+				return fmt.Errorf("revoke requires <quoin|infrastructure|provider> <name> <group> arguments")
+			}
+			resource, e := resourcePath(args[0])
+			if e != nil {
+				return e
+			}
+			if e := client.NewDefaultClient().DeleteAccess(resource, args[1], args[2]); e != nil {
+				return e
+			}
+			fmt.Fprintf(out, "Group %s's access on %s %s is revoked\n", args[2], args[0], args[1])
+			return nil
+		},
+	})
+
+	return command
+}
+
+// resourcePath validates the resource type given on command line
+func resourcePath(resource string) (string, error) {
+	switch resource {
+	case "quoin", "infrastructure", "provider":
+		return resource, nil
+	default:
+		return "", fmt.Errorf("Unsupported resource type %q. Use quoin, infrastructure or provider", resource)
+	}
+}
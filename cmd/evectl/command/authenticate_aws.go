@@ -2,6 +2,7 @@ package command
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 
@@ -45,7 +46,10 @@ func NewAuthenticateAwsCommand(out, err io.Writer) *cobra.Command {
 func authenticateAws(cmd *cobra.Command, args []string, out, err io.Writer) error {
 
 	// Retrieve flags
-	a := getAccount()
+	a, e := getAccount()
+	if e != nil {
+		return e
+	}
 	i := getIamRole(a)
 	r := getRegion(a)
 	p := getProfileName()
@@ -140,11 +144,14 @@ func getIamRole(account *aws.Account) string {
 	})
 }
 
-func getAccount() *aws.Account {
+func getAccount() (*aws.Account, error) {
 
 	c := client.NewDefaultClient()
 	p := c.GetProvider("aws")
-	a := aws.NewProvider(p)
+	a, err := aws.NewProvider(p)
+	if err != nil {
+		return nil, err
+	}
 
 	// account from flag, env, prompt
 	account = getFlagValue(account, "AWS_ACCOUNT", func() string {
@@ -154,5 +161,9 @@ func getAccount() *aws.Account {
 		return accountNames[i]
 	})
 
-	return a.GetAccount(account)
+	acct := a.GetAccount(account)
+	if acct == nil {
+		return nil, fmt.Errorf("AccountNotFound: account %s not found in provider aws", account)
+	}
+	return acct, nil
 }
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/client"
+	"github.com/spf13/cobra"
+)
+
+// List command flags
+var (
+	listOwner    string
+	listStatus   string
+	listQuoin    string
+	listProvider string
+	listSelector string
+	listLimit    int
+	listCursor   string
+	listAll      bool
+)
+
+// NewListCommand creates an instance of the ListCommand
+func NewListCommand(out, err io.Writer) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "list <quoins|infrastructures>",
+		Short: "List quoins or infrastructures",
+		Long: `List quoins or infrastructures page by page. Resources you are not authorized to read are listed by name only.
+Quoins are listed with name, status, owner, created and updated time. Infrastructures are listed with name, status,
+owner, quoin, provider slug, updated, last applied and last destroyed time`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("list requires <quoins|infrastructures> argument")
+			}
+			options := eve.ListOptions{
+				Owner:        eve.UserId(listOwner),
+				Quoin:        listQuoin,
+				ProviderSlug: listProvider,
+				Limit:        listLimit,
+			}
+			if listStatus != "" {
+				status, e := eve.ParseStatus(listStatus)
+				if e != nil {
+					return e
+				}
+				options.Status = &status
+			}
+			selector, e := eve.ParseSelector(listSelector)
+			if e != nil {
+				return e
+			}
+			options.Selector = selector
+
+			var list func(cursor string) (string, error)
+			switch args[0] {
+			case "quoin", "quoins":
+				if listQuoin != "" || listProvider != "" {
+					return fmt.Errorf("--quoin and --provider filters only apply to infrastructures")
+				}
+				list = func(cursor string) (string, error) {
+					quoins, e := client.NewDefaultClient().ListQuoins(options, cursor)
+					if e != nil {
+						return "", e
+					}
+					for _, quoin := range quoins.Items {
+						fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\n", quoin.Name, quoin.Status, quoin.Authorization.Owner,
+							formatTime(&quoin.CreatedAt), formatTime(&quoin.UpdatedAt))
+					}
+					return quoins.Next, nil
+				}
+			case "infrastructure", "infrastructures":
+				list = func(cursor string) (string, error) {
+					infras, e := client.NewDefaultClient().ListInfrastructures(options, cursor)
+					if e != nil {
+						return "", e
+					}
+					for _, infra := range infras.Items {
+						quoin := ""
+						if infra.Quoin != nil {
+							quoin = infra.Quoin.Name
+						}
+						fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", infra.Name, infra.Status, infra.Authorization.Owner, quoin, infra.ProviderSlug,
+							formatTime(&infra.UpdatedAt), formatTime(infra.LastAppliedAt), formatTime(infra.LastDestroyedAt))
+					}
+					return infras.Next, nil
+				}
+			default:
+				return fmt.Errorf("Unknown resource %s. Please use quoins or infrastructures", args[0])
+			}
+
+			cursor := listCursor
+			for {
+				next, e := list(cursor)
+				if e != nil {
+					return e
+				}
+				if next == "" {
+					return nil
+				}
+				if !listAll {
+					fmt.Fprintf(err, "More results with --cursor %s\n", next)
+					return nil
+				}
+				cursor = next
+			}
+		},
+	}
+	command.Flags().StringVar(&listOwner, "owner", "", "List resources owned by this user.")
+	command.Flags().StringVar(&listStatus, "status", "", "List resources in this status, e.g. deployed.")
+	command.Flags().StringVar(&listQuoin, "quoin", "", "List infrastructures using this quoin.")
+	command.Flags().StringVar(&listProvider, "provider", "", "List infrastructures using this provider slug, e.g. aws:dev.")
+	command.Flags().StringVarP(&listSelector, "selector", "l", "", "List resources matching this label selector, e.g. 'env=prod,team in (a,b)'.")
+	command.Flags().IntVar(&listLimit, "limit", 0, "Maximum number of resources per page.")
+	command.Flags().StringVar(&listCursor, "cursor", "", "Cursor of the page to list, printed by the previous page.")
+	command.Flags().BoolVar(&listAll, "all", false, "List all pages.")
+
+	return command
+}
+
+// formatTime prints t in RFC 3339 format, or "-" when it's not set
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
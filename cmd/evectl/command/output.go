@@ -0,0 +1,70 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/concur/eve/client"
+	"github.com/spf13/cobra"
+)
+
+// NewOutputCommand creates an instance of the OutputCommand
+func NewOutputCommand(out, err io.Writer) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "output <infrastructure> [key]",
+		Short: "Print outputs of an infrastructure",
+		Long: `Print outputs of an infrastructure's terraform state as key = value lines, or the value of one output.
+Sensitive outputs are printed as <sensitive> unless you are authorized to execute the infrastructure`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 && len(args) != 2 {
+				return fmt.Errorf("output requires <infrastructure> argument and an optional [key] argument")
+			}
+			if len(args) == 2 {
+				output, e := client.NewDefaultClient().GetInfrastructureOutput(args[0], args[1])
+				if e != nil {
+					return e
+				}
+				value, e := formatOutputValue(output.Value)
+				if e != nil {
+					return e
+				}
+				fmt.Fprintln(out, value)
+				return nil
+			}
+
+			outputs, e := client.NewDefaultClient().GetInfrastructureOutputs(args[0])
+			if e != nil {
+				return e
+			}
+			keys := make([]string, 0, len(outputs))
+			for key := range outputs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				value, e := formatOutputValue(outputs[key].Value)
+				if e != nil {
+					return e
+				}
+				fmt.Fprintf(out, "%s = %s\n", key, value)
+			}
+			return nil
+		},
+	}
+
+	return command
+}
+
+// formatOutputValue prints strings as they are, and lists and maps as JSON
+func formatOutputValue(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,168 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/client"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Provider schema file
+var providerFile string
+
+// NewProviderCommand creates an instance of the ProviderCommand
+func NewProviderCommand(out, err io.Writer) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "provider <create|update|list|get|delete>",
+		Short: "Manage eve providers",
+		Long:  `Used for creating, updating, listing, getting and deleting eve providers`,
+	}
+
+	createCommand := &cobra.Command{
+		Use:   "create --file FILE",
+		Short: "Create a provider",
+		Long:  `Create a provider from a YAML or JSON provider schema file`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, e := readProviderFile(providerFile)
+			if e != nil {
+				return e
+			}
+			if e := client.NewDefaultClient().CreateProvider(provider); e != nil {
+				return e
+			}
+			fmt.Fprintf(out, "Provider %s is created\n", provider.Name)
+			return nil
+		},
+	}
+	createCommand.Flags().StringVarP(&providerFile, "file", "f", "", "YAML or JSON provider schema file.")
+
+	updateCommand := &cobra.Command{
+		Use:   "update <name> --file FILE",
+		Short: "Update a provider",
+		Long:  `Replace a provider's schema from a YAML or JSON provider schema file`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("update requires <name> argument")
+			}
+			provider, e := readProviderFile(providerFile)
+			if e != nil {
+				return e
+			}
+			if e := client.NewDefaultClient().UpdateProvider(args[0], provider); e != nil {
+				return e
+			}
+			fmt.Fprintf(out, "Provider %s is updated\n", args[0])
+			return nil
+		},
+	}
+	updateCommand.Flags().StringVarP(&providerFile, "file", "f", "", "YAML or JSON provider schema file.")
+
+	command.AddCommand(createCommand)
+	command.AddCommand(updateCommand)
+
+	command.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List providers",
+		Long:  `List providers which you are authorized to read`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			providers, e := client.NewDefaultClient().GetProviders()
+			if e != nil {
+				return e
+			}
+			for _, provider := range providers {
+				fmt.Fprintf(out, "%s\t%s\n", provider.Name, provider.Schema.Type)
+			}
+			return nil
+		},
+	})
+
+	command.AddCommand(&cobra.Command{
+		Use:   "get <name>",
+		Short: "Get a provider",
+		Long:  `Print a provider's schema in JSON format`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("get requires <name> argument")
+			}
+			provider := client.NewDefaultClient().GetProvider(args[0])
+			data, e := json.MarshalIndent(provider, "", "  ")
+			if e != nil {
+				return e
+			}
+			fmt.Fprintln(out, string(data))
+			return nil
+		},
+	})
+
+	command.AddCommand(&cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a provider",
+		Long:  `Delete a provider which is not used by any infrastructure`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("delete requires <name> argument")
+			}
+			if e := client.NewDefaultClient().DeleteProvider(args[0]); e != nil {
+				return e
+			}
+			fmt.Fprintf(out, "Provider %s is deleted\n", args[0])
+			return nil
+		},
+	})
+
+	return command
+}
+
+// readProviderFile reads provider from YAML (.yaml, .yml) or JSON file
+func readProviderFile(path string) (*eve.Provider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("Provider schema file is missing. Please use --file flag")
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var data interface{}
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("Invalid YAML provider file %s: %s", path, err)
+		}
+		if content, err = json.Marshal(yamlToJson(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	var provider eve.Provider
+	if err := json.Unmarshal(content, &provider); err != nil {
+		return nil, fmt.Errorf("Invalid provider file %s: %s", path, err)
+	}
+	return &provider, nil
+}
+
+// yamlToJson converts YAML's map[interface{}]interface{} into JSON compatible map[string]interface{}
+func yamlToJson(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = yamlToJson(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = yamlToJson(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
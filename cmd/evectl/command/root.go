@@ -15,6 +15,10 @@ func NewRootCommand(in io.Reader, out, err io.Writer) *cobra.Command {
 	}
 
 	commands.AddCommand(NewAuthenticateCommand(out, err))
+	commands.AddCommand(NewAccessCommand(out, err))
+	commands.AddCommand(NewProviderCommand(out, err))
+	commands.AddCommand(NewListCommand(out, err))
+	commands.AddCommand(NewOutputCommand(out, err))
 
 	return commands
 }
@@ -1,31 +1,56 @@
 package eve
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type HealthService interface {
 	GetHealth() *HealthInfo
 }
 
 type QuoinService interface {
 	GetQuoin(name string) (*Quoin, error)
+	ListQuoins(options ListOptions) (*QuoinList, error)
 	GetQuoinArchive(id string) (*QuoinArchive, error)
+	GetQuoinArchives(quoinName string) ([]QuoinArchive, error)
 	GetQuoinArchiveIds(quoinName string) ([]string, error)
 	GetQuoinArchiveIdFromUri(archiveUri string) string
 	CreateQuoin(quoin *Quoin) (*Quoin, error)
 	CreateQuoinArchive(quoinArchive *QuoinArchive) error
 	DeleteQuoin(name string) error
 	DeleteQuoinArchive(id string) error
+	CollectQuoinArchives(dryRun bool) ([]QuoinArchive, error)
+	UpdateQuoinAccess(name string, group Group, mode PolicyMode) error
+	DeleteQuoinAccess(name string, group Group) error
+	TransferQuoinOwnership(name string, owner UserId) error
 }
 
 type InfrastructureService interface {
 	GetInfrastructure(name string) (*Infrastructure, error)
 	GetInfrastructuresByQuoin(quoinName string) ([]Infrastructure, error)
+	ListInfrastructures(options ListOptions) (*InfrastructureList, error)
 	GetInfrastructureState(name string) (map[string]interface{}, error)
+	GetInfrastructureOutputs(name string) (map[string]StateOutput, error)
 	CountInfrastructureByQuoin(quoinName string) (int, error)
 	CreateInfrastructure(infra *Infrastructure) error
 	DeleteInfrastructure(name string) error
 	DeleteInfrastructureState(name string) error
-	UpdateInfrastructureState(name string, state map[string]interface{}) error
+	UpdateInfrastructureState(name string, state map[string]interface{}, lockId string, force bool) error
+	GetInfrastructureStateLock(name string) (*StateLock, error)
+	LockInfrastructureState(name string, lock *StateLock) error
+	UnlockInfrastructureState(name string, id string) error
+	ForceUnlockInfrastructureState(name string) error
+	GetInfrastructureStateVersions(name string) ([]StateVersion, error)
+	GetInfrastructureStateVersion(name string, id string) (*StateVersion, error)
+	RollbackInfrastructureState(name string, id string) (*StateVersion, error)
+	RekeyInfrastructureStates(rotate bool) (int, error)
 	UpdateInfrastructureStatus(name string, status Status) error
 	UpdateInfrastructureError(name string, infraError error) error
+	UpdateInfrastructureAccess(name string, group Group, mode PolicyMode) error
+	DeleteInfrastructureAccess(name string, group Group) error
+	TransferInfrastructureOwnership(name string, owner UserId) error
 	SubscribeAsyncProc(subject Subject, handler InfrastructureAsyncHandler) error
 	PublishMessageToQueue(subject Subject, infra *Infrastructure) error
 }
@@ -47,20 +72,41 @@ type Error struct {
 }
 
 type Quoin struct {
-	Id            string        `json:"id,omitempty"`            // UUID for each entry. Generated by rethinkdb uuid() based on quoin.Name
-	Name          string        `json:"name"`                    // quoin unique name as db index field
-	ArchiveUri    string        `json:"archiveUri,omitempty"`    // quoin scheme://host:port/quoin/name/upload/
-	Variables     []QuoinVar    `json:"variables,omitempty"`     // quoin module input variables
-	Status        Status        `json:"status,omitempty"`        // quoin lifecycle status
-	Authorization Authorization `json:"authorization,omitempty"` // quoin authorization setting
+	Id            string            `json:"id,omitempty"`            // UUID for each entry. Generated by rethinkdb uuid() based on quoin.Name
+	Name          string            `json:"name"`                    // quoin unique name as db index field
+	ArchiveUri    string            `json:"archiveUri,omitempty"`    // quoin scheme://host:port/quoin/name/upload/
+	Variables     []QuoinVar        `json:"variables,omitempty"`     // quoin module input variables
+	Status        Status            `json:"status,omitempty"`        // quoin lifecycle status
+	Authorization Authorization     `json:"authorization,omitempty"` // quoin authorization setting
+	Labels        map[string]string `json:"labels,omitempty"`        // quoin labels for selector queries, e.g. team=sre
+	CreatedAt     time.Time         `json:"createdAt"`               // time the quoin was first created
+	UpdatedAt     time.Time         `json:"updatedAt"`               // time the quoin was last changed
 }
 
 // Quoin Archive content is a collection of terraform modules in tarball format
 type QuoinArchive struct {
-	Id            string
-	QuoinName     string // Archive will be linked to specific quoin instance
-	Modules       []byte
-	Authorization Authorization
+	Id            string        `json:"id"`
+	QuoinName     string        `json:"quoinName"` // Archive will be linked to specific quoin instance
+	Modules       []byte        `json:"modules,omitempty"`
+	Authorization Authorization `json:"authorization"`
+	Uploader      UserId        `json:"uploader"`  // user who uploaded the archive
+	Size          int           `json:"size"`      // size of Modules in bytes
+	Sha256        string        `json:"sha256"`    // hex encoded SHA-256 of Modules
+	CreatedAt     time.Time     `json:"createdAt"` // upload time
+}
+
+// ArchiveInUseError is returned when a quoin archive is still referenced and cannot be deleted
+type ArchiveInUseError struct {
+	Id              string
+	QuoinName       string
+	Infrastructures []string // names of not yet destroyed infrastructures pinned to the archive
+}
+
+func (e *ArchiveInUseError) Error() string {
+	if len(e.Infrastructures) == 0 {
+		return fmt.Sprintf("Quoin archive %s is the current archive of quoin %s", e.Id, e.QuoinName)
+	}
+	return fmt.Sprintf("Quoin archive %s is still used by infrastructures %s", e.Id, strings.Join(e.Infrastructures, ", "))
 }
 
 type QuoinVar struct {
@@ -69,18 +115,47 @@ type QuoinVar struct {
 }
 
 type Infrastructure struct {
-	Id            string                 `json:"id,omitempty"`            // UUID for each entry. Generated by rethinkdb uuid() based on name
-	Name          string                 `json:"name"`                    // infrastructure unique name as db index field
-	Quoin         *Quoin                 `json:"quoin"`                   // infrastructure quoin type
-	Variables     []QuoinVar             `json:"variables,omitempty"`     // infrastructure environment variables
-	State         map[string]interface{} `json:"state,omitempty"`         // Terraform state output
-	Status        Status                 `json:"status,omitempty"`        // infrastructure environment lifecycle status
-	Error         string                 `json:"error,omitempty"`         // infrastructure error while creating/deleting
-	Authorization Authorization          `json:"authorization,omitempty"` // infrastructure authorization setting
-	ProviderSlug  string                 `json:"providerSlug"`            // infrastructure provider in slug format <provider:schema-type> aws:account
-}
-
-// Team's permission on resource
+	Id              string                 `json:"id,omitempty"`              // UUID for each entry. Generated by rethinkdb uuid() based on name
+	Name            string                 `json:"name"`                      // infrastructure unique name as db index field
+	Quoin           *Quoin                 `json:"quoin"`                     // infrastructure quoin type
+	Variables       []QuoinVar             `json:"variables,omitempty"`       // infrastructure environment variables
+	State           map[string]interface{} `json:"state,omitempty"`           // Terraform state output
+	Status          Status                 `json:"status,omitempty"`          // infrastructure environment lifecycle status
+	Error           string                 `json:"error,omitempty"`           // infrastructure error while creating/deleting
+	Authorization   Authorization          `json:"authorization,omitempty"`   // infrastructure authorization setting
+	Organization    Organization           `json:"organization,omitempty"`    // creator's organization, whose key encrypts the state
+	ProviderSlug    string                 `json:"providerSlug"`              // infrastructure provider in slug format <provider:schema-type> aws:account
+	Role            string                 `json:"role,omitempty"`            // role to assume in provider's account, must be one of account's roles
+	Labels          map[string]string      `json:"labels,omitempty"`          // infrastructure labels for selector queries, e.g. env=prod
+	CreatedAt       time.Time              `json:"createdAt"`                 // time the infrastructure was first created
+	UpdatedAt       time.Time              `json:"updatedAt"`                 // time the infrastructure's status or state last changed
+	LastAppliedAt   *time.Time             `json:"lastAppliedAt,omitempty"`   // time the infrastructure was last deployed
+	LastDestroyedAt *time.Time             `json:"lastDestroyedAt,omitempty"` // time the infrastructure was last destroyed
+}
+
+// RecordTransition sets infrastructure's timestamps for moving to status to at time now
+func (infra *Infrastructure) RecordTransition(to Status, now time.Time) {
+	infra.UpdatedAt = now
+	switch to {
+	case DEPLOYED:
+		infra.LastAppliedAt = &now
+	case DESTROYED:
+		infra.LastDestroyedAt = &now
+	}
+}
+
+// Variable returns the value of infrastructure variable with given key
+func (infra *Infrastructure) Variable(key string) string {
+	for _, v := range infra.Variables {
+		if v.Key == key {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// Team's permission on resource. PolicyMode values are bit flags and can be combined,
+// e.g. POLICY_READ | POLICY_EXECUTE
 type PolicyMode int
 
 const (
@@ -89,11 +164,21 @@ const (
 	POLICY_READ
 	POLICY_WRITE
 	POLICY_EXECUTE
-	POLICY_READ_WRITE
-	POLICY_READ_EXECUTE
-	POLICY_WRITE_EXECUTE
 )
 
+const (
+	POLICY_READ_WRITE    = POLICY_READ | POLICY_WRITE
+	POLICY_READ_EXECUTE  = POLICY_READ | POLICY_EXECUTE
+	POLICY_WRITE_EXECUTE = POLICY_WRITE | POLICY_EXECUTE
+)
+
+// LegacyPolicyModes maps combined modes stored before PolicyMode values became bit flags to their bit flags
+var LegacyPolicyModes = map[PolicyMode]PolicyMode{
+	32:  POLICY_READ_WRITE,
+	64:  POLICY_READ_EXECUTE,
+	128: POLICY_WRITE_EXECUTE,
+}
+
 type Authorization struct {
 	// Owner could be a user, or an organization
 	Owner UserId
@@ -104,6 +189,9 @@ type Authorization struct {
 
 type Group string
 
+// Everyone belongs to public group
+const PUBLIC_GROUP Group = "public"
+
 type Organization Group
 
 type Team Group
@@ -116,8 +204,23 @@ type User struct {
 	Organization
 
 	Teams []Team
+
+	// Eve admin user can access and transfer ownership of every resource
+	Admin bool
+
+	// API level roles bound to the user
+	Roles []Role
 }
 
+// Role grants user the access to a set of Eve's API endpoints
+type Role string
+
+const (
+	ROLE_VIEWER   Role = "viewer"
+	ROLE_OPERATOR Role = "operator"
+	ROLE_ADMIN    Role = "admin"
+)
+
 type Status int
 
 // Resource lifecycle status in iota int
@@ -131,6 +234,27 @@ const (
 	FAILED
 )
 
+// AuditRecord keeps track of privileged operation on resource, e.g. ownership transfer
+type AuditRecord struct {
+	Id           string            `json:"id,omitempty"`
+	ResourceType string            `json:"resourceType"`
+	ResourceName string            `json:"resourceName"`
+	Action       AuditAction       `json:"action"`
+	Actor        UserId            `json:"actor"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Timestamp    time.Time         `json:"timestamp"`
+}
+
+type AuditAction string
+
+const (
+	AUDIT_TRANSFER_OWNERSHIP AuditAction = "transfer-ownership"
+	AUDIT_ROLLBACK_STATE     AuditAction = "rollback-state"
+	AUDIT_DELETE_STATE       AuditAction = "delete-state"
+	AUDIT_FORCE_UNLOCK_STATE AuditAction = "force-unlock-state"
+	AUDIT_FORCE_PUSH_STATE   AuditAction = "force-push-state"
+)
+
 type Subject string
 
 // NATS.io Message's "subject"
@@ -141,18 +265,33 @@ const (
 
 type ProviderService interface {
 	GetProvider(name string) (*Provider, error)
+	GetProviders() ([]Provider, error)
+	CreateProvider(provider *Provider) error
+	UpdateProvider(name string, provider *Provider) error
+	DeleteProvider(name string) error
 }
 
 type Provider struct {
-	Id            string `json:id,omitempty`
-	Name          string `json:name`
-	Schema        Schema
-	Authorization Authorization
+	Id            string        `json:"id,omitempty"`
+	Name          string        `json:"name"`
+	Schema        Schema        `json:"schema"`
+	Authorization Authorization `json:"authorization,omitempty"` // provider authorization setting
+	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
 }
 
 type Schema struct {
-	Type string      `json:type`
-	Data interface{} `json:data`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// ParseProviderSlug splits provider slug <provider:account> into provider name and account name
+func ParseProviderSlug(slug string) (provider string, account string, err error) {
+	s := strings.SplitN(slug, ":", 2)
+	if len(s) != 2 || s[0] == "" || s[1] == "" {
+		return "", "", fmt.Errorf("Invalid provider slug %q. Provider slug should be in <provider:account> format", slug)
+	}
+	return s[0], s[1], nil
 }
 
 const (
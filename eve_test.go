@@ -0,0 +1,39 @@
+package eve_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/concur/eve"
+)
+
+func TestParseProviderSlug(t *testing.T) {
+	provider, account, err := eve.ParseProviderSlug("aws:dev-account")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if provider != "aws" || account != "dev-account" {
+		t.Errorf("Unexpected provider slug parts: %v, %v", provider, account)
+	}
+
+	for _, slug := range []string{"", "aws", "aws:", ":account"} {
+		if _, _, err := eve.ParseProviderSlug(slug); err == nil {
+			t.Errorf("Provider slug %q should be invalid", slug)
+		}
+	}
+}
+
+func TestInfrastructure_RecordTransition(t *testing.T) {
+	infra := &eve.Infrastructure{Name: "dev"}
+	applied := time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)
+	infra.RecordTransition(eve.DEPLOYED, applied)
+	if !infra.UpdatedAt.Equal(applied) || infra.LastAppliedAt == nil || !infra.LastAppliedAt.Equal(applied) || infra.LastDestroyedAt != nil {
+		t.Errorf("DEPLOYED should set UpdatedAt and LastAppliedAt, got %#v", infra)
+	}
+
+	destroyed := applied.Add(time.Hour)
+	infra.RecordTransition(eve.DESTROYED, destroyed)
+	if !infra.LastAppliedAt.Equal(applied) || infra.LastDestroyedAt == nil || !infra.LastDestroyedAt.Equal(destroyed) {
+		t.Errorf("DESTROYED should keep LastAppliedAt and set LastDestroyedAt, got %#v", infra)
+	}
+}
@@ -0,0 +1,206 @@
+package httprouter
+
+import (
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	"github.com/concur/eve/service"
+	"github.com/julienschmidt/httprouter"
+)
+
+func putQuoinAccessHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	quoinService := service.NewQuoinService(user)
+
+	log.Printf("Invoke UpdateQuoinAccess API")
+	name := p.ByName(P_NAME)
+	group := eve.Group(p.ByName(P_GROUP))
+	mode, err := buildPolicyMode(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildPolicyMode returns error: %#v", err)
+		return
+	}
+	if err := quoinService.UpdateQuoinAccess(name, group, mode); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("UpdateQuoinAccess API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("UpdateQuoinAccess API completed: %v %v %v", name, group, mode)
+}
+
+func deleteQuoinAccessHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	quoinService := service.NewQuoinService(user)
+
+	log.Printf("Invoke DeleteQuoinAccess API")
+	name := p.ByName(P_NAME)
+	group := eve.Group(p.ByName(P_GROUP))
+	if err := quoinService.DeleteQuoinAccess(name, group); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("DeleteQuoinAccess API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("DeleteQuoinAccess API completed: %v %v", name, group)
+}
+
+func putInfraAccessHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke UpdateInfrastructureAccess API")
+	name := p.ByName(P_NAME)
+	group := eve.Group(p.ByName(P_GROUP))
+	mode, err := buildPolicyMode(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildPolicyMode returns error: %#v", err)
+		return
+	}
+	if err := infraSvc.UpdateInfrastructureAccess(name, group, mode); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("UpdateInfrastructureAccess API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("UpdateInfrastructureAccess API completed: %v %v %v", name, group, mode)
+}
+
+func deleteInfraAccessHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke DeleteInfrastructureAccess API")
+	name := p.ByName(P_NAME)
+	group := eve.Group(p.ByName(P_GROUP))
+	if err := infraSvc.DeleteInfrastructureAccess(name, group); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("DeleteInfrastructureAccess API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("DeleteInfrastructureAccess API completed: %v %v", name, group)
+}
+
+func putProviderAccessHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	providerService := service.NewProviderService(user)
+
+	log.Printf("Invoke UpdateProviderAccess API")
+	name := p.ByName(P_NAME)
+	group := eve.Group(p.ByName(P_GROUP))
+	mode, err := buildPolicyMode(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildPolicyMode returns error: %#v", err)
+		return
+	}
+	if err := providerService.UpdateProviderAccess(name, group, mode); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("UpdateProviderAccess API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("UpdateProviderAccess API completed: %v %v %v", name, group, mode)
+}
+
+func deleteProviderAccessHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	providerService := service.NewProviderService(user)
+
+	log.Printf("Invoke DeleteProviderAccess API")
+	name := p.ByName(P_NAME)
+	group := eve.Group(p.ByName(P_GROUP))
+	if err := providerService.DeleteProviderAccess(name, group); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("DeleteProviderAccess API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("DeleteProviderAccess API completed: %v %v", name, group)
+}
+
+func postQuoinOwnerHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	quoinService := service.NewQuoinService(user)
+
+	log.Printf("Invoke TransferQuoinOwnership API")
+	name := p.ByName(P_NAME)
+	owner, err := buildOwner(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildOwner returns error: %#v", err)
+		return
+	}
+	if err := quoinService.TransferQuoinOwnership(name, owner); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("TransferQuoinOwnership API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("TransferQuoinOwnership API completed: %v %v", name, owner)
+}
+
+func postInfraOwnerHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke TransferInfrastructureOwnership API")
+	name := p.ByName(P_NAME)
+	owner, err := buildOwner(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildOwner returns error: %#v", err)
+		return
+	}
+	if err := infraSvc.TransferInfrastructureOwnership(name, owner); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("TransferInfrastructureOwnership API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("TransferInfrastructureOwnership API completed: %v %v", name, owner)
+}
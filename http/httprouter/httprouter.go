@@ -10,22 +10,46 @@ import (
 
 const (
 	P_NAME        = "name"
+	P_GROUP       = "group"
+	P_ID          = "id"
+	P_KEY         = "key"
 	HEALTH_PATH   = "/health"
+	STATUS_PATH   = "/statuses"
 	PROVIDER_PATH = "/provider"
 	QUOIN_PATH    = "/quoin"
 	INFRA_PATH    = "/infrastructure"
 )
 
+// Terraform http backend locks and unlocks state with these methods on state's address
+const (
+	METHOD_LOCK   = "LOCK"
+	METHOD_UNLOCK = "UNLOCK"
+)
+
 const (
 	RESOURCE_NOT_EXIST = "Resource Does Not Exist"
+	API_FORBIDDEN      = "User is not allowed to access this API"
 )
 
 var (
-	PROVIDER_NAME_PATH    string = fmt.Sprintf("%s/:%s", PROVIDER_PATH, P_NAME)
-	QUOIN_NAME_PATH       string = fmt.Sprintf("%s/:%s", QUOIN_PATH, P_NAME)
-	QUOIN_ARCHIVE_PATH    string = fmt.Sprintf("%s/upload", QUOIN_NAME_PATH)
-	INFRA_NAME_PATH       string = fmt.Sprintf("%s/:%s", INFRA_PATH, P_NAME)
-	INFRA_NAME_STATE_PATH string = fmt.Sprintf("%s/state", INFRA_NAME_PATH)
+	PROVIDER_NAME_PATH        string = fmt.Sprintf("%s/:%s", PROVIDER_PATH, P_NAME)
+	QUOIN_NAME_PATH           string = fmt.Sprintf("%s/:%s", QUOIN_PATH, P_NAME)
+	QUOIN_ARCHIVE_PATH        string = fmt.Sprintf("%s/upload", QUOIN_NAME_PATH)
+	QUOIN_ARCHIVES_PATH       string = fmt.Sprintf("%s/archives", QUOIN_NAME_PATH)
+	QUOIN_ARCHIVE_ID_PATH     string = fmt.Sprintf("%s/:%s", QUOIN_ARCHIVES_PATH, P_ID)
+	INFRA_NAME_PATH           string = fmt.Sprintf("%s/:%s", INFRA_PATH, P_NAME)
+	INFRA_NAME_STATE_PATH     string = fmt.Sprintf("%s/state", INFRA_NAME_PATH)
+	INFRA_STATE_VERSIONS_PATH string = fmt.Sprintf("%s/versions", INFRA_NAME_STATE_PATH)
+	INFRA_STATE_VERSION_PATH  string = fmt.Sprintf("%s/:%s", INFRA_STATE_VERSIONS_PATH, P_ID)
+	INFRA_STATE_ROLLBACK_PATH string = fmt.Sprintf("%s/rollback", INFRA_STATE_VERSION_PATH)
+	INFRA_STATE_LOCK_PATH     string = fmt.Sprintf("%s/lock", INFRA_NAME_STATE_PATH)
+	INFRA_OUTPUTS_PATH        string = fmt.Sprintf("%s/outputs", INFRA_NAME_PATH)
+	INFRA_OUTPUT_PATH         string = fmt.Sprintf("%s/:%s", INFRA_OUTPUTS_PATH, P_KEY)
+	QUOIN_ACCESS_PATH         string = fmt.Sprintf("%s/access/:%s", QUOIN_NAME_PATH, P_GROUP)
+	INFRA_ACCESS_PATH         string = fmt.Sprintf("%s/access/:%s", INFRA_NAME_PATH, P_GROUP)
+	PROVIDER_ACCESS_PATH      string = fmt.Sprintf("%s/access/:%s", PROVIDER_NAME_PATH, P_GROUP)
+	QUOIN_OWNER_PATH          string = fmt.Sprintf("%s/owner", QUOIN_NAME_PATH)
+	INFRA_OWNER_PATH          string = fmt.Sprintf("%s/owner", INFRA_NAME_PATH)
 )
 
 type Router struct {
@@ -48,27 +72,81 @@ func (r *Router) RegisterRoute(apiServer *http.ApiServer) http.Handler {
 	healthService := service.NewHealthService()
 	r.httpRouter.GET(HEALTH_PATH, mChain(getHealthHandler(healthService)))
 	log.Infoln("GET", HEALTH_PATH, "with getHealthHandler")
-	r.httpRouter.GET(PROVIDER_NAME_PATH, mChain(getProviderHandler, authentication))
+	r.httpRouter.GET(STATUS_PATH, mChain(getStatusesHandler))
+	log.Infoln("GET", STATUS_PATH, "with getStatusesHandler")
+	r.httpRouter.GET(PROVIDER_NAME_PATH, mChain(getProviderHandler, authentication, authorization("GET", PROVIDER_NAME_PATH)))
 	log.Infoln("GET", PROVIDER_NAME_PATH, "with GetProviderHandler")
-	r.httpRouter.GET(QUOIN_NAME_PATH, mChain(getQuoinHandler, logging, authentication))
+	r.httpRouter.GET(PROVIDER_PATH, mChain(getProvidersHandler, authentication, authorization("GET", PROVIDER_PATH)))
+	log.Infoln("GET", PROVIDER_PATH, "with getProvidersHandler")
+	r.httpRouter.POST(PROVIDER_PATH, mChain(postProviderHandler, authentication, authorization("POST", PROVIDER_PATH)))
+	log.Infoln("POST", PROVIDER_PATH, "with postProviderHandler")
+	r.httpRouter.PUT(PROVIDER_NAME_PATH, mChain(putProviderHandler, authentication, authorization("PUT", PROVIDER_NAME_PATH)))
+	log.Infoln("PUT", PROVIDER_NAME_PATH, "with putProviderHandler")
+	r.httpRouter.DELETE(PROVIDER_NAME_PATH, mChain(deleteProviderHandler, authentication, authorization("DELETE", PROVIDER_NAME_PATH)))
+	log.Infoln("DELETE", PROVIDER_NAME_PATH, "with deleteProviderHandler")
+	r.httpRouter.GET(QUOIN_PATH, mChain(getQuoinsHandler, authentication, authorization("GET", QUOIN_PATH)))
+	log.Infoln("GET", QUOIN_PATH, "with getQuoinsHandler")
+	r.httpRouter.GET(QUOIN_NAME_PATH, mChain(getQuoinHandler, logging, authentication, authorization("GET", QUOIN_NAME_PATH)))
 	log.Infoln("GET", QUOIN_NAME_PATH, "with getQuoinHandler")
-	r.httpRouter.GET(INFRA_NAME_PATH, mChain(getInfraHandler, authentication))
+	r.httpRouter.GET(QUOIN_ARCHIVES_PATH, mChain(getQuoinArchivesHandler, authentication, authorization("GET", QUOIN_ARCHIVES_PATH)))
+	log.Infoln("GET", QUOIN_ARCHIVES_PATH, "with getQuoinArchivesHandler")
+	r.httpRouter.GET(QUOIN_ARCHIVE_ID_PATH, mChain(getQuoinArchiveHandler, authentication, authorization("GET", QUOIN_ARCHIVE_ID_PATH)))
+	log.Infoln("GET", QUOIN_ARCHIVE_ID_PATH, "with getQuoinArchiveHandler")
+	r.httpRouter.GET(INFRA_PATH, mChain(getInfrasHandler, authentication, authorization("GET", INFRA_PATH)))
+	log.Infoln("GET", INFRA_PATH, "with getInfrasHandler")
+	r.httpRouter.GET(INFRA_NAME_PATH, mChain(getInfraHandler, authentication, authorization("GET", INFRA_NAME_PATH)))
 	log.Infoln("GET", INFRA_NAME_PATH, "with getInfraHandler")
-	r.httpRouter.GET(INFRA_NAME_STATE_PATH, mChain(getInfraStateHandler, authentication))
+	r.httpRouter.GET(INFRA_NAME_STATE_PATH, mChain(getInfraStateHandler, authentication, authorization("GET", INFRA_NAME_STATE_PATH)))
 	log.Infoln("GET", INFRA_NAME_STATE_PATH, "with getInfraStateHandler")
-	r.httpRouter.POST(QUOIN_PATH, mChain(postQuoinHandler(apiServer), authentication))
+	r.httpRouter.GET(INFRA_STATE_VERSIONS_PATH, mChain(getInfraStateVersionsHandler, authentication, authorization("GET", INFRA_STATE_VERSIONS_PATH)))
+	log.Infoln("GET", INFRA_STATE_VERSIONS_PATH, "with getInfraStateVersionsHandler")
+	r.httpRouter.GET(INFRA_STATE_VERSION_PATH, mChain(getInfraStateVersionHandler, authentication, authorization("GET", INFRA_STATE_VERSION_PATH)))
+	log.Infoln("GET", INFRA_STATE_VERSION_PATH, "with getInfraStateVersionHandler")
+	r.httpRouter.GET(INFRA_STATE_LOCK_PATH, mChain(getInfraStateLockHandler, authentication, authorization("GET", INFRA_STATE_LOCK_PATH)))
+	log.Infoln("GET", INFRA_STATE_LOCK_PATH, "with getInfraStateLockHandler")
+	r.httpRouter.GET(INFRA_OUTPUTS_PATH, mChain(getInfraOutputsHandler, authentication, authorization("GET", INFRA_OUTPUTS_PATH)))
+	log.Infoln("GET", INFRA_OUTPUTS_PATH, "with getInfraOutputsHandler")
+	r.httpRouter.GET(INFRA_OUTPUT_PATH, mChain(getInfraOutputHandler, authentication, authorization("GET", INFRA_OUTPUT_PATH)))
+	log.Infoln("GET", INFRA_OUTPUT_PATH, "with getInfraOutputHandler")
+	r.httpRouter.POST(QUOIN_PATH, mChain(postQuoinHandler(apiServer), authentication, authorization("POST", QUOIN_PATH)))
 	log.Infoln("POST", QUOIN_PATH, "with postQuoinHandler")
-	r.httpRouter.POST(QUOIN_ARCHIVE_PATH, mChain(postQuoinArchiveHandler, authentication))
+	r.httpRouter.POST(QUOIN_ARCHIVE_PATH, mChain(postQuoinArchiveHandler, authentication, authorization("POST", QUOIN_ARCHIVE_PATH)))
 	log.Infoln("POST", QUOIN_ARCHIVE_PATH, "with postQuoinArchiveHandler")
-	r.httpRouter.POST(INFRA_PATH, mChain(postInfraHandler, authentication))
+	r.httpRouter.POST(INFRA_PATH, mChain(postInfraHandler, authentication, authorization("POST", INFRA_PATH)))
 	log.Infoln("POST", INFRA_PATH, "with postInfraHandler")
-	r.httpRouter.POST(INFRA_NAME_STATE_PATH, mChain(postInfraStateHandler, authentication))
+	r.httpRouter.POST(INFRA_NAME_STATE_PATH, mChain(postInfraStateHandler, authentication, authorization("POST", INFRA_NAME_STATE_PATH)))
 	log.Infoln("POST", INFRA_NAME_STATE_PATH, "with postInfraStateHandler")
-	r.httpRouter.DELETE(QUOIN_NAME_PATH, mChain(deleteQuoinHandler, authentication))
+	r.httpRouter.POST(INFRA_STATE_ROLLBACK_PATH, mChain(postInfraStateRollbackHandler, authentication, authorization("POST", INFRA_STATE_ROLLBACK_PATH)))
+	log.Infoln("POST", INFRA_STATE_ROLLBACK_PATH, "with postInfraStateRollbackHandler")
+	r.httpRouter.Handle(METHOD_LOCK, INFRA_NAME_STATE_PATH, mChain(lockInfraStateHandler, authentication, authorization(METHOD_LOCK, INFRA_NAME_STATE_PATH)))
+	log.Infoln(METHOD_LOCK, INFRA_NAME_STATE_PATH, "with lockInfraStateHandler")
+	r.httpRouter.Handle(METHOD_UNLOCK, INFRA_NAME_STATE_PATH, mChain(unlockInfraStateHandler, authentication, authorization(METHOD_UNLOCK, INFRA_NAME_STATE_PATH)))
+	log.Infoln(METHOD_UNLOCK, INFRA_NAME_STATE_PATH, "with unlockInfraStateHandler")
+	r.httpRouter.DELETE(QUOIN_NAME_PATH, mChain(deleteQuoinHandler, authentication, authorization("DELETE", QUOIN_NAME_PATH)))
 	log.Infoln("DELETE", QUOIN_NAME_PATH, "with deleteQuoinHandler")
-	r.httpRouter.DELETE(INFRA_NAME_PATH, mChain(deleteInfraHandler, authentication))
+	r.httpRouter.DELETE(QUOIN_ARCHIVE_ID_PATH, mChain(deleteQuoinArchiveHandler, authentication, authorization("DELETE", QUOIN_ARCHIVE_ID_PATH)))
+	log.Infoln("DELETE", QUOIN_ARCHIVE_ID_PATH, "with deleteQuoinArchiveHandler")
+	r.httpRouter.DELETE(INFRA_NAME_PATH, mChain(deleteInfraHandler, authentication, authorization("DELETE", INFRA_NAME_PATH)))
 	log.Infoln("DELETE", INFRA_NAME_PATH, "with deleteInfraHandler")
-	r.httpRouter.DELETE(INFRA_NAME_STATE_PATH, mChain(deleteInfraStateHandler, authentication))
+	r.httpRouter.DELETE(INFRA_NAME_STATE_PATH, mChain(deleteInfraStateHandler, authentication, authorization("DELETE", INFRA_NAME_STATE_PATH)))
 	log.Infoln("DELETE", INFRA_NAME_STATE_PATH, "with deleteInfraStateHandler")
+	r.httpRouter.DELETE(INFRA_STATE_LOCK_PATH, mChain(forceUnlockInfraStateHandler, authentication, authorization("DELETE", INFRA_STATE_LOCK_PATH)))
+	log.Infoln("DELETE", INFRA_STATE_LOCK_PATH, "with forceUnlockInfraStateHandler")
+	r.httpRouter.PUT(QUOIN_ACCESS_PATH, mChain(putQuoinAccessHandler, authentication, authorization("PUT", QUOIN_ACCESS_PATH)))
+	log.Infoln("PUT", QUOIN_ACCESS_PATH, "with putQuoinAccessHandler")
+	r.httpRouter.DELETE(QUOIN_ACCESS_PATH, mChain(deleteQuoinAccessHandler, authentication, authorization("DELETE", QUOIN_ACCESS_PATH)))
+	log.Infoln("DELETE", QUOIN_ACCESS_PATH, "with deleteQuoinAccessHandler")
+	r.httpRouter.PUT(INFRA_ACCESS_PATH, mChain(putInfraAccessHandler, authentication, authorization("PUT", INFRA_ACCESS_PATH)))
+	log.Infoln("PUT", INFRA_ACCESS_PATH, "with putInfraAccessHandler")
+	r.httpRouter.DELETE(INFRA_ACCESS_PATH, mChain(deleteInfraAccessHandler, authentication, authorization("DELETE", INFRA_ACCESS_PATH)))
+	log.Infoln("DELETE", INFRA_ACCESS_PATH, "with deleteInfraAccessHandler")
+	r.httpRouter.PUT(PROVIDER_ACCESS_PATH, mChain(putProviderAccessHandler, authentication, authorization("PUT", PROVIDER_ACCESS_PATH)))
+	log.Infoln("PUT", PROVIDER_ACCESS_PATH, "with putProviderAccessHandler")
+	r.httpRouter.DELETE(PROVIDER_ACCESS_PATH, mChain(deleteProviderAccessHandler, authentication, authorization("DELETE", PROVIDER_ACCESS_PATH)))
+	log.Infoln("DELETE", PROVIDER_ACCESS_PATH, "with deleteProviderAccessHandler")
+	r.httpRouter.POST(QUOIN_OWNER_PATH, mChain(postQuoinOwnerHandler, authentication, authorization("POST", QUOIN_OWNER_PATH)))
+	log.Infoln("POST", QUOIN_OWNER_PATH, "with postQuoinOwnerHandler")
+	r.httpRouter.POST(INFRA_OWNER_PATH, mChain(postInfraOwnerHandler, authentication, authorization("POST", INFRA_OWNER_PATH)))
+	log.Infoln("POST", INFRA_OWNER_PATH, "with postInfraOwnerHandler")
 	return r.httpRouter
 }
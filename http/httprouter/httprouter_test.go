@@ -1,8 +1,11 @@
 package httprouter
 
 import (
+	"context"
 	"github.com/concur/eve"
 	eveHttp "github.com/concur/eve/http"
+	"github.com/concur/eve/service"
+	"github.com/concur/eve/service/memory"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"net/http/httptest"
@@ -55,3 +58,230 @@ func TestRouter_RegisterRoute(t *testing.T) {
 		t.Errorf("RegisterRoute should add Health endpoint hanlder for \"/health\" path. Without dependencies, request should returns HTTP error code 503. Return code: %v, header: %#v, body: %#v", w.Code, w.Header(), w.Body.String())
 	}
 }
+
+func TestRouter_authorization(t *testing.T) {
+	okHandler := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	}
+	router := httprouter.New()
+	router.DELETE(INFRA_NAME_PATH, mChain(okHandler, authorization(http.MethodDelete, INFRA_NAME_PATH)))
+
+	cases := []struct {
+		user *eve.User
+		code int
+	}{
+		{&eve.User{Id: "viewer", Roles: []eve.Role{eve.ROLE_VIEWER}}, http.StatusForbidden},
+		{&eve.User{Id: "operator", Roles: []eve.Role{eve.ROLE_OPERATOR}}, http.StatusOK},
+		{&eve.User{Id: "nobody"}, http.StatusForbidden},
+		{&eve.User{Id: "root", Admin: true}, http.StatusOK},
+		{&eve.User{Id: eve.AGENT_USER}, http.StatusOK},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest(http.MethodDelete, "/infrastructure/test", nil)
+		r = r.WithContext(context.WithValue(r.Context(), eveHttp.CTX_USER, c.user))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("authorization for user %#v should return HTTP code %v. Return code: %v, body: %#v", c.user, c.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRouter_authorizationAdminRoute(t *testing.T) {
+	operator := &eve.User{Id: "operator", Roles: []eve.Role{eve.ROLE_OPERATOR}}
+	if authorizedRoute(operator, http.MethodPost, INFRA_OWNER_PATH) {
+		t.Errorf("Operator role should not be allowed to transfer ownership")
+	}
+	admin := &eve.User{Id: "admin", Roles: []eve.Role{eve.ROLE_ADMIN}}
+	if !authorizedRoute(admin, http.MethodPost, INFRA_OWNER_PATH) {
+		t.Errorf("Admin role should be allowed to transfer ownership")
+	}
+	if !authorizedRoute(admin, http.MethodGet, INFRA_NAME_PATH) {
+		t.Errorf("Admin role should be allowed to read infrastructure")
+	}
+}
+
+func TestRouter_getStatusesHandler(t *testing.T) {
+	router := httprouter.New()
+	router.GET(STATUS_PATH, getStatusesHandler)
+	r, _ := http.NewRequest(http.MethodGet, STATUS_PATH, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	response_body := w.Body.String()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("getStatusesHandler returns HTTP error code: %v, body: %#v", w.Code, response_body)
+	}
+
+	if !strings.Contains(response_body, "{\"name\":\"validated\",\"value\":2,\"transitions\":[\"running\",\"failed\"]}") {
+		t.Errorf("getStatusesHandler should return lifecycle statuses with their transitions, got %s", response_body)
+	}
+}
+
+func TestRouter_providerHandlers(t *testing.T) {
+	store := memory.NewStore()
+	service.SetDefaultStore(store)
+	defer service.SetDefaultStore(nil)
+
+	admin := &eve.User{Id: "root", Organization: "concur", Admin: true}
+	router := httprouter.New()
+	router.POST(PROVIDER_PATH, postProviderHandler)
+	router.GET(PROVIDER_NAME_PATH, getProviderHandler)
+
+	body := `{"name":"aws","schema":{"type":"aws","data":[{"name":"dev","id":123456789012,"roles":["eve-deployer"],"regions":["us-west-2"],"authType":1}]}}`
+	r, _ := http.NewRequest(http.MethodPost, PROVIDER_PATH, strings.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), eveHttp.CTX_USER, admin))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("postProviderHandler should create provider. Return code: %v, body: %#v", w.Code, w.Body.String())
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, PROVIDER_PATH, strings.NewReader(`{"name":"gcp","schema":{"type":"gcp","data":[]}}`))
+	r = r.WithContext(context.WithValue(r.Context(), eveHttp.CTX_USER, admin))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "\"field\":\"schema.data\"") {
+		t.Errorf("postProviderHandler should reject invalid provider. Return code: %v, body: %#v", w.Code, w.Body.String())
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/provider/aws", nil)
+	r = r.WithContext(context.WithValue(r.Context(), eveHttp.CTX_USER, admin))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "\"name\":\"aws\"") {
+		t.Errorf("getProviderHandler should return stored provider. Return code: %v, body: %#v", w.Code, w.Body.String())
+	}
+}
+
+func TestRouter_stateLockHandlers(t *testing.T) {
+	store := memory.NewStore()
+	service.SetDefaultStore(store)
+	defer service.SetDefaultStore(nil)
+	owner := &eve.User{Id: "alice", Organization: "concur"}
+	store.InsertInfrastructure(&eve.Infrastructure{
+		Name:          "dev",
+		Quoin:         &eve.Quoin{Name: "k8s"},
+		Status:        eve.DEPLOYED,
+		Authorization: eve.Authorization{Owner: owner.Id, GroupAccess: map[eve.Group]eve.PolicyMode{eve.Group(owner.Id): eve.POLICY_ALL}},
+	})
+
+	router := httprouter.New()
+	router.Handle(METHOD_LOCK, INFRA_NAME_STATE_PATH, lockInfraStateHandler)
+	router.Handle(METHOD_UNLOCK, INFRA_NAME_STATE_PATH, unlockInfraStateHandler)
+	router.POST(INFRA_NAME_STATE_PATH, postInfraStateHandler)
+	router.DELETE(INFRA_STATE_LOCK_PATH, forceUnlockInfraStateHandler)
+	send := func(method string, url string, body string, md5 string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, url, strings.NewReader(body))
+		if md5 != "" {
+			r.Header.Set("Content-MD5", md5)
+		}
+		r = r.WithContext(context.WithValue(r.Context(), eveHttp.CTX_USER, owner))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	state := `{"serial":1,"lineage":"abc"}`
+	if w := send(METHOD_LOCK, "/infrastructure/dev/state", `{"ID":"lock-1","Operation":"OperationTypeApply","Who":"alice@laptop"}`, ""); w.Code != http.StatusOK {
+		t.Fatalf("LOCK should lock state. Return code: %v, body: %#v", w.Code, w.Body.String())
+	}
+	if w := send(METHOD_LOCK, "/infrastructure/dev/state", `{"ID":"lock-2"}`, ""); w.Code != http.StatusLocked || !strings.Contains(w.Body.String(), `"ID":"lock-1"`) {
+		t.Errorf("LOCK of locked state should return 423 with the lock. Return code: %v, body: %#v", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/infrastructure/dev/state", state, ""); w.Code != http.StatusLocked {
+		t.Errorf("POST without lock id should return 423. Return code: %v", w.Code)
+	}
+	if w := send(http.MethodPost, "/infrastructure/dev/state?ID=lock-1", state, "invalid"); w.Code != http.StatusBadRequest {
+		t.Errorf("POST with mismatched Content-MD5 should return 400. Return code: %v", w.Code)
+	}
+	// base64 encoded MD5 of state
+	if w := send(http.MethodPost, "/infrastructure/dev/state?ID=lock-1", state, "45G5HCNecuFfH0yogJWPtg=="); w.Code != http.StatusOK {
+		t.Errorf("POST with lock id should store state. Return code: %v, body: %#v", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodDelete, "/infrastructure/dev/state/lock", "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Force unlock by non admin user should return 403. Return code: %v", w.Code)
+	}
+	if w := send(METHOD_UNLOCK, "/infrastructure/dev/state", `{"ID":"lock-1"}`, ""); w.Code != http.StatusOK {
+		t.Errorf("UNLOCK should unlock state. Return code: %v, body: %#v", w.Code, w.Body.String())
+	}
+}
+
+func TestRouter_accessHandlers(t *testing.T) {
+	store := memory.NewStore()
+	service.SetDefaultStore(store)
+	defer service.SetDefaultStore(nil)
+	owner := &eve.User{Id: "alice", Organization: "concur"}
+	member := &eve.User{Id: "bob", Organization: "concur"}
+	authorization := eve.Authorization{Owner: owner.Id, GroupAccess: map[eve.Group]eve.PolicyMode{
+		eve.Group(owner.Id): eve.POLICY_ALL,
+		eve.Group("concur"): eve.POLICY_READ,
+	}}
+	store.InsertQuoin(&eve.Quoin{Name: "k8s", Authorization: authorization})
+	store.InsertInfrastructure(&eve.Infrastructure{Name: "dev", Quoin: &eve.Quoin{Name: "k8s"}, Status: eve.DEPLOYED, Authorization: authorization})
+
+	router := httprouter.New()
+	router.PUT(QUOIN_ACCESS_PATH, putQuoinAccessHandler)
+	router.DELETE(QUOIN_ACCESS_PATH, deleteQuoinAccessHandler)
+	router.PUT(INFRA_ACCESS_PATH, putInfraAccessHandler)
+	router.DELETE(INFRA_ACCESS_PATH, deleteInfraAccessHandler)
+	router.POST(QUOIN_OWNER_PATH, postQuoinOwnerHandler)
+	router.POST(INFRA_OWNER_PATH, postInfraOwnerHandler)
+	send := func(user *eve.User, method string, url string, body string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, url, strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), eveHttp.CTX_USER, user))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, url := range []string{"/quoin/k8s/access/sre", "/infrastructure/dev/access/sre"} {
+		if w := send(member, http.MethodPut, url, `{"policy":"read"}`); w.Code != http.StatusForbidden {
+			t.Errorf("PUT %s by non owner should return 403. Return code: %v", url, w.Code)
+		}
+		if w := send(member, http.MethodDelete, url, ""); w.Code != http.StatusForbidden {
+			t.Errorf("DELETE %s by non owner should return 403. Return code: %v", url, w.Code)
+		}
+		if w := send(owner, http.MethodPut, url, `{"policy":"read"}`); w.Code != http.StatusOK {
+			t.Errorf("PUT %s by owner should grant access. Return code: %v, body: %#v", url, w.Code, w.Body.String())
+		}
+	}
+
+	admin := &eve.User{Id: "carol", Admin: true}
+	for _, url := range []string{"/quoin/k8s/owner", "/infrastructure/dev/owner"} {
+		if w := send(owner, http.MethodPost, url, `{"owner":"bob"}`); w.Code != http.StatusForbidden {
+			t.Errorf("POST %s by non admin user should return 403. Return code: %v", url, w.Code)
+		}
+		if w := send(admin, http.MethodPost, url, `{"owner":"bob"}`); w.Code != http.StatusOK {
+			t.Errorf("POST %s by admin user should transfer ownership. Return code: %v, body: %#v", url, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRouter_infraReadHandlersForbidden(t *testing.T) {
+	store := memory.NewStore()
+	service.SetDefaultStore(store)
+	defer service.SetDefaultStore(nil)
+	store.InsertInfrastructure(&eve.Infrastructure{
+		Name:          "dev",
+		Quoin:         &eve.Quoin{Name: "k8s"},
+		Status:        eve.DEPLOYED,
+		Authorization: eve.Authorization{Owner: "alice", GroupAccess: map[eve.Group]eve.PolicyMode{eve.Group("alice"): eve.POLICY_ALL}},
+	})
+	outsider := &eve.User{Id: "dave", Organization: "acme"}
+
+	router := httprouter.New()
+	router.GET(INFRA_NAME_PATH, getInfraHandler)
+	router.GET(INFRA_STATE_VERSIONS_PATH, getInfraStateVersionsHandler)
+	router.GET(INFRA_OUTPUTS_PATH, getInfraOutputsHandler)
+	router.GET(INFRA_OUTPUT_PATH, getInfraOutputHandler)
+	for _, url := range []string{"/infrastructure/dev", "/infrastructure/dev/state/versions", "/infrastructure/dev/outputs", "/infrastructure/dev/outputs/dns"} {
+		r, _ := http.NewRequest(http.MethodGet, url, nil)
+		r = r.WithContext(context.WithValue(r.Context(), eveHttp.CTX_USER, outsider))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("GET %s by unauthorized user should return 403. Return code: %v", url, w.Code)
+		}
+	}
+}
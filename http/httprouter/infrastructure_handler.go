@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
+	eveProvider "github.com/concur/eve/provider"
 	"github.com/concur/eve/service"
 	"github.com/julienschmidt/httprouter"
+	"io/ioutil"
 	"net/http"
 )
 
@@ -21,7 +23,7 @@ func getInfraHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params
 	name := p.ByName(P_NAME)
 	infrastructure, err := infraSvc.GetInfrastructure(name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), statusCode(err))
 		log.Printf("GetInfrastructure API returns error: %#v", err)
 		return
 	}
@@ -53,6 +55,39 @@ func getInfraHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params
 	})
 }
 
+// getInfrasHandler returns a page of infrastructures filtered by owner, status, quoin, provider and selector query parameters
+func getInfrasHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke ListInfrastructures API")
+	options, err := buildListOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildListOptions returns error: %#v", err)
+		return
+	}
+	infras, err := infraSvc.ListInfrastructures(options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("ListInfrastructures API returns error: %#v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(infras); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding infrastructures returns error: %#v", err)
+		return
+	}
+	log.Printf("ListInfrastructures API returns %d infrastructures", len(infras.Items))
+}
+
 func getInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	user, err := getUser(r)
 	if err != nil {
@@ -65,7 +100,7 @@ func getInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.P
 	name := p.ByName(P_NAME)
 	state, err := infraSvc.GetInfrastructureState(name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), statusCode(err))
 		log.Printf("GetInfrastructureState API returns error: %#v", err)
 		return
 	}
@@ -74,13 +109,8 @@ func getInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.P
 		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
 		log.Println("GetInfrastructureState API returns: nil")
 		return
-	} else {
-		// Terraform store user credentials on remote state server. We should propose the change to terraform
-		username, _, _ := r.BasicAuth()
-		if username != "terraform" && state["remote"] != nil {
-			delete(state, "remote")
-		}
 	}
+	hideRemoteState(r, state)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -89,7 +119,178 @@ func getInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.P
 		log.Printf("Encoding infrastructure state returns error: %#v", err)
 		return
 	}
-	log.Printf("GetInfrastructureState API returns: %s\n", state)
+	// Never log the state itself, it carries secrets
+	serial, _ := eve.StateSerial(state)
+	log.Printf("GetInfrastructureState API returns state serial %d of %s", serial, name)
+}
+
+// hideRemoteState removes remote state settings from state unless terraform requests it.
+// Terraform store user credentials on remote state server. We should propose the change to terraform
+func hideRemoteState(r *http.Request, state map[string]interface{}) {
+	username, _, _ := r.BasicAuth()
+	if username != "terraform" && state["remote"] != nil {
+		delete(state, "remote")
+	}
+}
+
+func getInfraStateVersionsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke GetInfrastructureStateVersions API")
+	name := p.ByName(P_NAME)
+	versions, err := infraSvc.GetInfrastructureStateVersions(name)
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("GetInfrastructureStateVersions API returns error: %#v", err)
+		return
+	}
+	if versions == nil {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("GetInfrastructureStateVersions API returns: nil")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(versions); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding state versions returns error: %#v", err)
+		return
+	}
+	log.Printf("GetInfrastructureStateVersions API returns %d versions of %s", len(versions), name)
+}
+
+func getInfraStateVersionHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke GetInfrastructureStateVersion API")
+	name, id := p.ByName(P_NAME), p.ByName(P_ID)
+	version, err := infraSvc.GetInfrastructureStateVersion(name, id)
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("GetInfrastructureStateVersion API returns error: %#v", err)
+		return
+	}
+	if version == nil {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("GetInfrastructureStateVersion API returns: nil")
+		return
+	}
+	hideRemoteState(r, version.State)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(version); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding state version returns error: %#v", err)
+		return
+	}
+	log.Printf("GetInfrastructureStateVersion API returns version %s of %s", id, name)
+}
+
+// getInfraOutputsHandler returns outputs of infrastructure's state keyed by name, with sensitive values
+// masked unless the user is authorized to execute the infrastructure
+func getInfraOutputsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke GetInfrastructureOutputs API")
+	name := p.ByName(P_NAME)
+	outputs, err := infraSvc.GetInfrastructureOutputs(name)
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("GetInfrastructureOutputs API returns error: %#v", err)
+		return
+	}
+	if outputs == nil {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("GetInfrastructureOutputs API returns: nil")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(outputs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding outputs returns error: %#v", err)
+		return
+	}
+	log.Printf("GetInfrastructureOutputs API returns %d outputs of %s", len(outputs), name)
+}
+
+func getInfraOutputHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke GetInfrastructureOutput API")
+	name, key := p.ByName(P_NAME), p.ByName(P_KEY)
+	outputs, err := infraSvc.GetInfrastructureOutputs(name)
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("GetInfrastructureOutput API returns error: %#v", err)
+		return
+	}
+	output, ok := outputs[key]
+	if !ok {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("GetInfrastructureOutput API returns: nil")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(output); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding output returns error: %#v", err)
+		return
+	}
+	log.Printf("GetInfrastructureOutput API returns output %s of %s", key, name)
+}
+
+func postInfraStateRollbackHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke RollbackInfrastructureState API")
+	name, id := p.ByName(P_NAME), p.ByName(P_ID)
+	version, err := infraSvc.RollbackInfrastructureState(name, id)
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("RollbackInfrastructureState API returns error: %#v", err)
+		return
+	}
+	version.State = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(version); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding state version returns error: %#v", err)
+		return
+	}
+	log.Printf("RollbackInfrastructureState API restores version %s of %s as %s", id, name, version.Id)
 }
 
 func postInfraHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
@@ -108,7 +309,12 @@ func postInfraHandler(w http.ResponseWriter, r *http.Request, p httprouter.Param
 		return
 	}
 	if err := infraSvc.CreateInfrastructure(infrastructure); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errs, ok := err.(eveProvider.ValidationErrors); ok {
+			writeValidationErrors(w, errs)
+			log.Printf("CreateInfrastructure API rejects request: %v", errs)
+			return
+		}
+		http.Error(w, err.Error(), statusCode(err))
 		log.Printf("CreateInfrastructure API returns error: %#v", err)
 		return
 	}
@@ -127,14 +333,32 @@ func postInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.
 
 	log.Printf("Invoke UpdateInfrastructureState API")
 	name := p.ByName(P_NAME)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("Reading infrastructure state returns error: %#v\n", err)
+		return
+	}
+	if err := checkContentMD5(r, body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("UpdateInfrastructureState API rejects request: %v\n", err)
+		return
+	}
 	var state map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+	if err := json.Unmarshal(body, &state); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Printf("Decode infrastructure state creation request returns error: %#v\n", err)
 		return
 	}
-	if err := infraSvc.UpdateInfrastructureState(name, state); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// Terraform sends the id of its lock while it holds the state's lock. Admins may force a stale state with force=true
+	query := r.URL.Query()
+	if err := infraSvc.UpdateInfrastructureState(name, state, query.Get("ID"), query.Get("force") == "true"); err != nil {
+		if lockErr, ok := err.(*eve.StateLockError); ok {
+			writeStateLocked(w, lockErr)
+			log.Printf("UpdateInfrastructureState API rejects request of user %s: %v", user.Id, lockErr)
+			return
+		}
+		http.Error(w, err.Error(), statusCode(err))
 		log.Printf("UpdateInfrastructureState API returns error: %#v", err)
 		return
 	}
@@ -155,7 +379,7 @@ func deleteInfraHandler(w http.ResponseWriter, r *http.Request, p httprouter.Par
 	name := p.ByName(P_NAME)
 
 	if err := infraSvc.DeleteInfrastructure(name); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), statusCode(err))
 		log.Printf("DeleteInfrastructure API returns error: %#v", err)
 		return
 	}
@@ -176,7 +400,7 @@ func deleteInfraStateHandler(w http.ResponseWriter, r *http.Request, p httproute
 	log.Printf("Invoke DeleteInfrastructureState API")
 	name := p.ByName(P_NAME)
 	if err := infraSvc.DeleteInfrastructureState(name); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), statusCode(err))
 		log.Printf("DeleteInfrastructureState API returns error: %#v", err)
 		return
 	}
@@ -185,3 +409,117 @@ func deleteInfraStateHandler(w http.ResponseWriter, r *http.Request, p httproute
 	w.WriteHeader(http.StatusOK)
 	log.Printf("DeleteInfrastructureState API accepted request for %#v\n", name)
 }
+
+func getInfraStateLockHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke GetInfrastructureStateLock API")
+	name := p.ByName(P_NAME)
+	lock, err := infraSvc.GetInfrastructureStateLock(name)
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("GetInfrastructureStateLock API returns error: %#v", err)
+		return
+	}
+	if lock == nil {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("GetInfrastructureStateLock API returns: nil")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(lock); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding state lock returns error: %#v", err)
+		return
+	}
+	log.Printf("GetInfrastructureStateLock API returns lock %s of %s", lock.ID, name)
+}
+
+func lockInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke LockInfrastructureState API")
+	name := p.ByName(P_NAME)
+	var lock eve.StateLock
+	if err := json.NewDecoder(r.Body).Decode(&lock); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("Decode state lock request returns error: %#v\n", err)
+		return
+	}
+	if err := infraSvc.LockInfrastructureState(name, &lock); err != nil {
+		if lockErr, ok := err.(*eve.StateLockError); ok {
+			writeStateLocked(w, lockErr)
+			log.Printf("LockInfrastructureState API rejects request of user %s: %v", user.Id, lockErr)
+			return
+		}
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("LockInfrastructureState API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("LockInfrastructureState API locks %s with %s", name, lock.ID)
+}
+
+func unlockInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke UnlockInfrastructureState API")
+	name := p.ByName(P_NAME)
+	var lock eve.StateLock
+	if err := json.NewDecoder(r.Body).Decode(&lock); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("Decode state unlock request returns error: %#v\n", err)
+		return
+	}
+	if err := infraSvc.UnlockInfrastructureState(name, lock.ID); err != nil {
+		if lockErr, ok := err.(*eve.StateLockError); ok {
+			writeStateLocked(w, lockErr)
+			log.Printf("UnlockInfrastructureState API rejects request of user %s: %v", user.Id, lockErr)
+			return
+		}
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("UnlockInfrastructureState API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("UnlockInfrastructureState API unlocks %s with %s", name, lock.ID)
+}
+
+func forceUnlockInfraStateHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	infraSvc := service.NewInfrastructureService(user)
+
+	log.Printf("Invoke ForceUnlockInfrastructureState API")
+	name := p.ByName(P_NAME)
+	if err := infraSvc.ForceUnlockInfrastructureState(name); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("ForceUnlockInfrastructureState API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("ForceUnlockInfrastructureState API completed: %v", name)
+}
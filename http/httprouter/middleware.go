@@ -39,7 +39,28 @@ func authentication(routeHandler httprouter.Handle) httprouter.Handle {
 		r, err = eveHttp.Authentication(w, r)
 		if err != nil {
 			log.Infoln("Unauthorized request: ", err)
+			return
 		}
 		routeHandler(w, r, p)
 	}
 }
+
+// authorization denies the request when none of user's roles is allowed to access the API endpoint.
+// It has to be chained after authentication
+func authorization(method string, path string) middleware {
+	return func(routeHandler httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+			user, err := getUser(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if !authorizedRoute(user, method, path) {
+				log.Infof("User %s with roles %v is forbidden to access %s %s", user.Id, user.Roles, method, path)
+				http.Error(w, API_FORBIDDEN, http.StatusForbidden)
+				return
+			}
+			routeHandler(w, r, p)
+		}
+	}
+}
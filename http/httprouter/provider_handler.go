@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	log "github.com/Sirupsen/logrus"
+	eveProvider "github.com/concur/eve/provider"
 	"github.com/concur/eve/service"
 	"github.com/julienschmidt/httprouter"
 )
@@ -22,7 +23,7 @@ func getProviderHandler(w http.ResponseWriter, r *http.Request, p httprouter.Par
 	name := p.ByName(P_NAME)
 	provider, err := providerService.GetProvider(name)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), statusCode(err))
 		log.Printf("GetProvider API returns error: %#v", err)
 		return
 	}
@@ -42,3 +43,140 @@ func getProviderHandler(w http.ResponseWriter, r *http.Request, p httprouter.Par
 	}
 	log.Printf("GetProvider API returns: %#v", provider)
 }
+
+// getProvidersHandler returns the list of providers user is authorized to read
+func getProvidersHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	providerService := service.NewProviderService(user)
+
+	log.Printf("Invoke GetProviders API")
+	providers, err := providerService.GetProviders()
+	if err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("GetProviders API returns error: %#v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(providers); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding providers returns error: %#v", err)
+		return
+	}
+	log.Printf("GetProviders API returns %d providers", len(providers))
+}
+
+func postProviderHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	providerService := service.NewProviderService(user)
+
+	log.Println("Invoke CreateProvider API")
+	provider, err := buildProvider(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildProvider returns error: %#v", err)
+		return
+	}
+	bindAuthorization(provider, r)
+	if err := providerService.CreateProvider(provider); err != nil {
+		if errs, ok := err.(eveProvider.ValidationErrors); ok {
+			writeValidationErrors(w, errs)
+			log.Printf("CreateProvider API rejects invalid provider: %v", errs)
+			return
+		}
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("CreateProvider API returns error: %#v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(provider); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding provider returns error: %#v", err)
+		return
+	}
+	log.Printf("CreateProvider API returns: %#v", provider.Name)
+}
+
+func putProviderHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	providerService := service.NewProviderService(user)
+
+	log.Println("Invoke UpdateProvider API")
+	name := p.ByName(P_NAME)
+	provider, err := buildProvider(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildProvider returns error: %#v", err)
+		return
+	}
+	if provider.Name != "" && provider.Name != name {
+		http.Error(w, "Provider name cannot be changed", http.StatusBadRequest)
+		log.Printf("UpdateProvider API rejects renaming provider %s to %s", name, provider.Name)
+		return
+	}
+	if err := providerService.UpdateProvider(name, provider); err != nil {
+		if errs, ok := err.(eveProvider.ValidationErrors); ok {
+			writeValidationErrors(w, errs)
+			log.Printf("UpdateProvider API rejects invalid provider: %v", errs)
+			return
+		}
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("UpdateProvider API returns error: %#v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("UpdateProvider API completed: %v", name)
+}
+
+func deleteProviderHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	providerService := service.NewProviderService(user)
+
+	log.Printf("Invoke DeleteProvider API")
+	name := p.ByName(P_NAME)
+	if err := providerService.DeleteProvider(name); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("DeleteProvider API returns error: %#v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("DeleteProvider API completed: %v", name)
+}
+
+// writeValidationErrors responds 400 Bad Request with the invalid fields of provider
+func writeValidationErrors(w http.ResponseWriter, errs eveProvider.ValidationErrors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(struct {
+		Error  string                       `json:"error"`
+		Fields eveProvider.ValidationErrors `json:"fields"`
+	}{
+		errs.Error(),
+		errs,
+	}); err != nil {
+		log.Printf("Encoding provider validation errors returns error: %#v", err)
+	}
+}
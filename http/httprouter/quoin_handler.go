@@ -2,6 +2,7 @@ package httprouter
 
 import (
 	"encoding/json"
+	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
 	eveHttp "github.com/concur/eve/http"
@@ -9,6 +10,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 )
 
 func getQuoinHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
@@ -44,6 +46,39 @@ func getQuoinHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params
 	log.Printf("GetQuoin API returns: %#v", quoin)
 }
 
+// getQuoinsHandler returns a page of quoins filtered by owner, status and selector query parameters
+func getQuoinsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	quoinService := service.NewQuoinService(user)
+
+	log.Printf("Invoke ListQuoins API")
+	options, err := buildListOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		log.Printf("buildListOptions returns error: %#v", err)
+		return
+	}
+	quoins, err := quoinService.ListQuoins(options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("ListQuoins API returns error: %#v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(quoins); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding quoins returns error: %#v", err)
+		return
+	}
+	log.Printf("ListQuoins API returns %d quoins", len(quoins.Items))
+}
+
 // postQuoinHandler returns the httprouter.Handle func for POST /quoin request
 func postQuoinHandler(apiServer *eveHttp.ApiServer) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
@@ -122,6 +157,107 @@ func postQuoinArchiveHandler(w http.ResponseWriter, r *http.Request, p httproute
 	log.Printf("CreateQuoinArchive API returns: %#v", quoinArchive.Id)
 }
 
+// getQuoinArchivesHandler returns quoin's archive history without archive contents, newest first
+func getQuoinArchivesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	quoinService := service.NewQuoinService(user)
+
+	log.Printf("Invoke GetQuoinArchives API")
+	name := p.ByName(P_NAME)
+	quoinArchives, err := quoinService.GetQuoinArchives(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("GetQuoinArchives API returns error: %#v", err)
+		return
+	}
+	if quoinArchives == nil {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("GetQuoinArchives API returns: nil")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(quoinArchives); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding quoin archives returns error: %#v", err)
+		return
+	}
+	log.Printf("GetQuoinArchives API returns %d archives of quoin %s", len(quoinArchives), name)
+}
+
+// getQuoinArchiveHandler downloads quoin archive's tar.gz content
+func getQuoinArchiveHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	quoinService := service.NewQuoinService(user)
+
+	log.Printf("Invoke GetQuoinArchive API")
+	name, id := p.ByName(P_NAME), p.ByName(P_ID)
+	quoinArchive, err := quoinService.GetQuoinArchive(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("GetQuoinArchive API returns error: %#v", err)
+		return
+	}
+	if quoinArchive == nil || quoinArchive.QuoinName != name {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("GetQuoinArchive API returns: nil")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-%s.tar.gz\"", name, id))
+	w.Header().Set("Content-Length", strconv.Itoa(len(quoinArchive.Modules)))
+	if quoinArchive.Sha256 != "" {
+		w.Header().Set("ETag", fmt.Sprintf("\"%s\"", quoinArchive.Sha256))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(quoinArchive.Modules); err != nil {
+		log.Printf("Writing quoin archive returns error: %#v", err)
+		return
+	}
+	log.Printf("GetQuoinArchive API returns archive %s of quoin %s", id, name)
+}
+
+func deleteQuoinArchiveHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	user, err := getUser(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	quoinService := service.NewQuoinService(user)
+
+	log.Printf("Invoke DeleteQuoinArchive API")
+	name, id := p.ByName(P_NAME), p.ByName(P_ID)
+	quoinArchive, err := quoinService.GetQuoinArchive(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("DeleteQuoinArchive API returns error: %#v", err)
+		return
+	}
+	if quoinArchive == nil || quoinArchive.QuoinName != name {
+		http.Error(w, RESOURCE_NOT_EXIST, http.StatusNotFound)
+		log.Println("DeleteQuoinArchive API returns: nil")
+		return
+	}
+	if err := quoinService.DeleteQuoinArchive(id); err != nil {
+		http.Error(w, err.Error(), statusCode(err))
+		log.Printf("DeleteQuoinArchive API returns error: %#v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	log.Printf("DeleteQuoinArchive API completed: archive %s of quoin %s", id, name)
+}
+
 func deleteQuoinHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	user, err := getUser(r)
 	if err != nil {
@@ -0,0 +1,88 @@
+package httprouter
+
+import (
+	"net/http"
+
+	"github.com/concur/eve"
+)
+
+// route is an API endpoint identified by HTTP method and httprouter path
+type route struct {
+	method string
+	path   string
+}
+
+var viewerRoutes = []route{
+	{http.MethodGet, PROVIDER_PATH},
+	{http.MethodGet, PROVIDER_NAME_PATH},
+	{http.MethodGet, QUOIN_PATH},
+	{http.MethodGet, QUOIN_NAME_PATH},
+	{http.MethodGet, QUOIN_ARCHIVES_PATH},
+	{http.MethodGet, QUOIN_ARCHIVE_ID_PATH},
+	{http.MethodGet, INFRA_PATH},
+	{http.MethodGet, INFRA_NAME_PATH},
+	{http.MethodGet, INFRA_NAME_STATE_PATH},
+	{http.MethodGet, INFRA_STATE_VERSIONS_PATH},
+	{http.MethodGet, INFRA_STATE_VERSION_PATH},
+	{http.MethodGet, INFRA_STATE_LOCK_PATH},
+	{http.MethodGet, INFRA_OUTPUTS_PATH},
+	{http.MethodGet, INFRA_OUTPUT_PATH},
+}
+
+var operatorRoutes = append([]route{
+	{http.MethodPost, QUOIN_PATH},
+	{http.MethodPost, QUOIN_ARCHIVE_PATH},
+	{http.MethodPost, INFRA_PATH},
+	{http.MethodPost, INFRA_NAME_STATE_PATH},
+	{http.MethodPost, INFRA_STATE_ROLLBACK_PATH},
+	{METHOD_LOCK, INFRA_NAME_STATE_PATH},
+	{METHOD_UNLOCK, INFRA_NAME_STATE_PATH},
+	{http.MethodDelete, QUOIN_NAME_PATH},
+	{http.MethodDelete, QUOIN_ARCHIVE_ID_PATH},
+	{http.MethodDelete, INFRA_NAME_PATH},
+	{http.MethodPut, QUOIN_ACCESS_PATH},
+	{http.MethodDelete, QUOIN_ACCESS_PATH},
+	{http.MethodPut, INFRA_ACCESS_PATH},
+	{http.MethodDelete, INFRA_ACCESS_PATH},
+}, viewerRoutes...)
+
+var adminRoutes = append([]route{
+	{http.MethodPost, PROVIDER_PATH},
+	{http.MethodPut, PROVIDER_NAME_PATH},
+	{http.MethodDelete, PROVIDER_NAME_PATH},
+	{http.MethodPut, PROVIDER_ACCESS_PATH},
+	{http.MethodDelete, PROVIDER_ACCESS_PATH},
+	{http.MethodDelete, INFRA_NAME_STATE_PATH},
+	{http.MethodDelete, INFRA_STATE_LOCK_PATH},
+	{http.MethodPost, QUOIN_OWNER_PATH},
+	{http.MethodPost, INFRA_OWNER_PATH},
+}, operatorRoutes...)
+
+// rolePermissions maps each role to the API endpoints the role is allowed to access
+var rolePermissions = map[eve.Role]map[route]bool{
+	eve.ROLE_VIEWER:   routeSet(viewerRoutes),
+	eve.ROLE_OPERATOR: routeSet(operatorRoutes),
+	eve.ROLE_ADMIN:    routeSet(adminRoutes),
+}
+
+func routeSet(routes []route) map[route]bool {
+	set := make(map[route]bool, len(routes))
+	for _, r := range routes {
+		set[r] = true
+	}
+	return set
+}
+
+// authorizedRoute reports whether any of user's roles allows the API endpoint.
+// System user (terraform) and eve admin users can access all of APIs
+func authorizedRoute(user *eve.User, method string, path string) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	for _, role := range user.Roles {
+		if rolePermissions[role][route{method, path}] {
+			return true
+		}
+	}
+	return false
+}
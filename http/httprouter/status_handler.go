@@ -0,0 +1,44 @@
+package httprouter
+
+import (
+	"encoding/json"
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// statusInfo describes a lifecycle status and the statuses infrastructure can move to from it
+type statusInfo struct {
+	Name        string       `json:"name"`
+	Value       int          `json:"value"`
+	Transitions []eve.Status `json:"transitions"`
+}
+
+func getStatusesHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	log.Printf("Invoke GetStatuses API")
+
+	statuses := make([]statusInfo, 0, len(eve.Statuses()))
+	for _, status := range eve.Statuses() {
+		transitions := eve.InfrastructureTransitions[status]
+		if transitions == nil {
+			transitions = []eve.Status{}
+		}
+		statuses = append(statuses, statusInfo{
+			Name:        status.String(),
+			Value:       int(status),
+			Transitions: transitions,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(struct {
+		Statuses []statusInfo `json:"statuses"`
+	}{statuses}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Printf("Encoding statuses returns error: %#v", err)
+		return
+	}
+	log.Printf("GetStatuses API returns: %#v", statuses)
+}
@@ -1,11 +1,16 @@
 package httprouter
 
 import (
+	"crypto/md5"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
 	eveHttp "github.com/concur/eve/http"
+	"github.com/concur/eve/service"
 	"net/http"
+	"strconv"
 )
 
 func buildQuoin(r *http.Request, apiServer *eveHttp.ApiServer) (*eve.Quoin, error) {
@@ -17,6 +22,9 @@ func buildQuoin(r *http.Request, apiServer *eveHttp.ApiServer) (*eve.Quoin, erro
 	if err != nil {
 		return nil, err
 	}
+	if err := eve.ValidateLabels(quoin.Labels); err != nil {
+		return nil, err
+	}
 	quoin.ArchiveUri = fmt.Sprintf("%s://%s%s/quoin/%s/upload", apiServer.Scheme, apiServer.DNS, apiServer.Addr, quoin.Name)
 	quoin.Status = eve.DEFAULT
 	bindAuthorization(&quoin, r)
@@ -32,14 +40,98 @@ func buildInfrastructure(r *http.Request) (*eve.Infrastructure, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := eve.ValidateLabels(infrastructure.Labels); err != nil {
+		return nil, err
+	}
 
 	bindAuthorization(&infrastructure, r)
 	return &infrastructure, nil
 }
 
+func buildProvider(r *http.Request) (*eve.Provider, error) {
+	if r.Body == nil {
+		return nil, fmt.Errorf("Empty request body is invalid for provider request")
+	}
+	var provider eve.Provider
+	if err := json.NewDecoder(r.Body).Decode(&provider); err != nil {
+		return nil, err
+	}
+	return &provider, nil
+}
+
+// AccessRequest is the request body of PUT /{quoin,infrastructure}/:name/access/:group
+type AccessRequest struct {
+	Policy string `json:"policy"` // comma separated permissions, e.g. "read,execute"
+}
+
+func buildPolicyMode(r *http.Request) (eve.PolicyMode, error) {
+	if r.Body == nil {
+		return eve.POLICY_NONE, fmt.Errorf("Empty request body is invalid for access request")
+	}
+	var accessReq AccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&accessReq); err != nil {
+		return eve.POLICY_NONE, err
+	}
+	return eve.ParsePolicyMode(accessReq.Policy)
+}
+
+// OwnerRequest is the request body of POST /{quoin,infrastructure}/:name/owner
+type OwnerRequest struct {
+	Owner string `json:"owner"`
+}
+
+func buildOwner(r *http.Request) (eve.UserId, error) {
+	if r.Body == nil {
+		return "", fmt.Errorf("Empty request body is invalid for ownership transfer request")
+	}
+	var ownerReq OwnerRequest
+	if err := json.NewDecoder(r.Body).Decode(&ownerReq); err != nil {
+		return "", err
+	}
+	if ownerReq.Owner == "" {
+		return "", fmt.Errorf("New owner is missing in ownership transfer request")
+	}
+	return eve.UserId(ownerReq.Owner), nil
+}
+
+// buildListOptions parses list request's owner, status, quoin, provider, selector, limit and cursor query parameters
+func buildListOptions(r *http.Request) (eve.ListOptions, error) {
+	query := r.URL.Query()
+	options := eve.ListOptions{
+		Owner:        eve.UserId(query.Get("owner")),
+		Quoin:        query.Get("quoin"),
+		ProviderSlug: query.Get("provider"),
+	}
+	selector, err := eve.ParseSelector(query.Get("selector"))
+	if err != nil {
+		return options, err
+	}
+	options.Selector = selector
+	if status := query.Get("status"); status != "" {
+		s, err := eve.ParseStatus(status)
+		if err != nil {
+			return options, err
+		}
+		options.Status = &s
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return options, fmt.Errorf("Invalid limit %q", limit)
+		}
+		options.Limit = n
+	}
+	after, err := service.DecodeCursor(query.Get("cursor"))
+	if err != nil {
+		return options, err
+	}
+	options.After = after
+	return options, nil
+}
+
 func getUser(r *http.Request) (*eve.User, error) {
-	user := r.Context().Value(eveHttp.CTX_USER).(*eve.User)
-	if user == nil {
+	user, ok := r.Context().Value(eveHttp.CTX_USER).(*eve.User)
+	if !ok || user == nil {
 		return nil, fmt.Errorf("User information is missing.")
 	}
 	return user, nil
@@ -54,11 +146,46 @@ func bindAuthorization(resource eve.Authorizable, r *http.Request) (eve.Authoriz
 	auth := eve.Authorization{
 		Owner: user.Id,
 		GroupAccess: map[eve.Group]eve.PolicyMode{
-			eve.Group(user.Id):           eve.POLICY_ALL,
-			eve.Group(user.Organization): eve.POLICY_READ,
-			eve.Group("public"):          eve.POLICY_NONE,
+			eve.Group(user.Id): eve.POLICY_ALL,
+			eve.PUBLIC_GROUP:   eve.POLICY_NONE,
 		},
 	}
 	resource.BindAuthorization(auth)
 	return resource, nil
 }
+
+// statusCode maps service error to http status code
+func statusCode(err error) int {
+	switch err.(type) {
+	case *eve.TransitionError, *eve.ArchiveInUseError, *eve.StaleStateError:
+		return http.StatusConflict
+	case *eve.StateLockError:
+		return http.StatusLocked
+	case *eve.ForbiddenError:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeStateLocked responds the lock holding the state like terraform http backend, so terraform reports the holder
+func writeStateLocked(w http.ResponseWriter, lockErr *eve.StateLockError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusLocked)
+	if err := json.NewEncoder(w).Encode(lockErr.Lock); err != nil {
+		log.Printf("Encoding state lock returns error: %#v", err)
+	}
+}
+
+// checkContentMD5 verifies body against request's base64 encoded Content-MD5 header when it's set
+func checkContentMD5(r *http.Request, body []byte) error {
+	expected := r.Header.Get("Content-MD5")
+	if expected == "" {
+		return nil
+	}
+	sum := md5.Sum(body)
+	if actual := base64.StdEncoding.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("Content-MD5 %s doesn't match request body's %s", expected, actual)
+	}
+	return nil
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
+	"github.com/concur/eve/pkg/config"
 	"github.com/concur/eve/pkg/vault"
 	"net/http"
+	"strings"
+	"sync"
 )
 
 const (
@@ -14,6 +17,19 @@ const (
 	AUTHENTICATION_FAILURE = "User authentication failure"
 )
 
+var (
+	authConfigOnce sync.Once
+	authConfig     *config.AuthorizationConfig
+)
+
+// authorizationConfig returns the authorization config read from environment variables on first use
+func authorizationConfig() *config.AuthorizationConfig {
+	authConfigOnce.Do(func() {
+		authConfig = config.NewAuthorizationConfig()
+	})
+	return authConfig
+}
+
 func Authentication(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
 	username, password, ok := r.BasicAuth()
 	if !ok {
@@ -24,6 +40,8 @@ func Authentication(w http.ResponseWriter, r *http.Request) (*http.Request, erro
 	secretPath := fmt.Sprintf("secret/user/%s", username)
 	user, err := vault.GetLogicalData(secretPath)
 	if err != nil {
+		log.Infof("%s Name: %s, Error: %v", AUTHENTICATION_FAILURE, username, err)
+		http.Error(w, AUTHENTICATION_FAILURE, http.StatusUnauthorized)
 		return nil, err
 	}
 	if user["name"] != username || user["password"] != password {
@@ -32,18 +50,64 @@ func Authentication(w http.ResponseWriter, r *http.Request) (*http.Request, erro
 		return nil, fmt.Errorf("%s:%s", AUTHENTICATION_FAILURE, username)
 	}
 	log.Infoln("User logins:", username)
-	r = setUserContext(r)
+	r = setUserContext(r, user)
 	return r, nil
 }
 
-func setUserContext(r *http.Request) *http.Request {
+// isAdmin checks eve admin users from config (EVE_ADMIN_USERS) and user's "admin" flag in Vault
+func isAdmin(authConfig *config.AuthorizationConfig, username string, user map[string]interface{}) bool {
+	if authConfig.IsAdminUser(username) {
+		return true
+	}
+	switch admin := user["admin"].(type) {
+	case bool:
+		return admin
+	case string:
+		return admin == "true"
+	}
+	return false
+}
+
+// bindRoles collects user's roles from config role bindings (EVE_ROLE_BINDINGS) and user's "roles" in Vault
+func bindRoles(authConfig *config.AuthorizationConfig, evUser *eve.User, user map[string]interface{}) []eve.Role {
+	var roles []eve.Role
+	if evUser.Admin {
+		roles = append(roles, eve.ROLE_ADMIN)
+	}
+	for role, subjects := range authConfig.RoleBindings {
+		for _, subject := range subjects {
+			for _, group := range evUser.Groups() {
+				if eve.Group(subject) == group {
+					roles = append(roles, eve.Role(role))
+				}
+			}
+		}
+	}
+	if vaultRoles, ok := user["roles"].(string); ok {
+		for _, role := range strings.Split(vaultRoles, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, eve.Role(role))
+			}
+		}
+	}
+	if len(roles) == 0 && authConfig.DefaultRole != "" {
+		roles = append(roles, eve.Role(authConfig.DefaultRole))
+	}
+	return roles
+}
+
+func setUserContext(r *http.Request, user map[string]interface{}) *http.Request {
 	username, _, _ := r.BasicAuth()
+	authConfig := authorizationConfig()
 
 	// TODO(weiteng.huang): User creation/retrieval will be from User service
-	ctx := context.WithValue(r.Context(), CTX_USER, &eve.User{
+	evUser := &eve.User{
 		Id:           eve.UserId(username),
 		Organization: eve.Organization("concur"),
 		Teams:        []eve.Team{eve.Team(username)},
-	})
+		Admin:        isAdmin(authConfig, username, user),
+	}
+	evUser.Roles = bindRoles(authConfig, evUser, user)
+	ctx := context.WithValue(r.Context(), CTX_USER, evUser)
 	return r.WithContext(ctx)
 }
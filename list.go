@@ -0,0 +1,59 @@
+package eve
+
+// ListOptions filters and paginates resource listings. Empty fields don't filter.
+// Results are ordered by name.
+type ListOptions struct {
+	Owner        UserId
+	Status       *Status
+	Quoin        string // infrastructure's quoin name
+	ProviderSlug string // infrastructure's provider slug, e.g. aws:dev
+	Selector     Selector
+	After        string // exclusive name to start listing after
+	Limit        int    // maximum number of results, 0 is unlimited
+}
+
+// QuoinList is a page of quoins. Next is the cursor of the following page, which is empty on the last page
+type QuoinList struct {
+	Items []Quoin `json:"items"`
+	Next  string  `json:"next,omitempty"`
+}
+
+// InfrastructureList is a page of infrastructures. Next is the cursor of the following page, which is empty on the last page
+type InfrastructureList struct {
+	Items []Infrastructure `json:"items"`
+	Next  string           `json:"next,omitempty"`
+}
+
+// MatchQuoin reports whether quoin passes options' filters, excluding pagination
+func (options ListOptions) MatchQuoin(quoin *Quoin) bool {
+	if options.Owner != "" && quoin.Authorization.Owner != options.Owner {
+		return false
+	}
+	if options.Status != nil && quoin.Status != *options.Status {
+		return false
+	}
+	if !options.Selector.Matches(quoin.Labels) {
+		return false
+	}
+	return options.After == "" || quoin.Name > options.After
+}
+
+// MatchInfrastructure reports whether infrastructure passes options' filters, excluding pagination
+func (options ListOptions) MatchInfrastructure(infra *Infrastructure) bool {
+	if options.Owner != "" && infra.Authorization.Owner != options.Owner {
+		return false
+	}
+	if options.Status != nil && infra.Status != *options.Status {
+		return false
+	}
+	if options.Quoin != "" && (infra.Quoin == nil || infra.Quoin.Name != options.Quoin) {
+		return false
+	}
+	if options.ProviderSlug != "" && infra.ProviderSlug != options.ProviderSlug {
+		return false
+	}
+	if !options.Selector.Matches(infra.Labels) {
+		return false
+	}
+	return options.After == "" || infra.Name > options.After
+}
@@ -22,15 +22,25 @@ const (
 	DEFAULT_DB_NAME     = "eve"
 	DEFAULT_QUEUE_PORT  = "4222"
 	DEFAULT_ENVIRONMENT = "DEV"
+	DEFAULT_ROLE        = "viewer"
+
+	DEFAULT_STATE_KEY_PREFIX = "eve-state-"
+
+	STATUS_FORMAT_NAME   = "name"   // statuses are serialized as names, e.g. "validated"
+	STATUS_FORMAT_NUMBER = "number" // statuses are serialized as legacy numbers, e.g. 2
+
+	STORE_RETHINKDB = "rethinkdb"
+	STORE_BOLT      = "bolt"
 )
 
 type ApiServerConfig struct {
-	Port     string
-	DNS      string
-	Scheme   string
-	Hostname string
-	CertFile string
-	KeyFile  string
+	Port         string
+	DNS          string
+	Scheme       string
+	Hostname     string
+	CertFile     string
+	KeyFile      string
+	StatusFormat string
 }
 
 type RethinkDbConfig struct {
@@ -45,6 +55,19 @@ type RethinkDbConfig struct {
 	TLSConfig     *tls.Config
 }
 
+// StoreConfig selects eve's store backend, e.g. "bolt:/var/lib/eve/eve.db"
+type StoreConfig struct {
+	Type string
+	Path string // bolt file path
+}
+
+// StateEncryptionConfig enables encryption of infrastructure states at rest with vault transit keys,
+// one key per organization named KeyPrefix + organization
+type StateEncryptionConfig struct {
+	TransitMount string // mount of vault transit backend, states are stored in plaintext when it's empty
+	KeyPrefix    string
+}
+
 type NatsConfig struct {
 	Url            string
 	AllowReconnect bool
@@ -53,6 +76,12 @@ type NatsConfig struct {
 	Timeout        time.Duration
 }
 
+type AuthorizationConfig struct {
+	AdminUsers   []string
+	RoleBindings map[string][]string // role name to bound users, teams and organizations
+	DefaultRole  string              // role of authenticated user without any role binding
+}
+
 type SystemConfig struct {
 	Hostname    string
 	Version     string
@@ -80,14 +109,19 @@ func NewApiServerConfig() *ApiServerConfig {
 	if keyfile == "" {
 		keyfile = filepath.Join("/opt", "tls", "eve-server-key.pem")
 	}
+	statusFormat := os.Getenv("EVE_STATUS_FORMAT")
+	if statusFormat != STATUS_FORMAT_NUMBER {
+		statusFormat = STATUS_FORMAT_NAME
+	}
 	hostname, _ := os.Hostname()
 	return &ApiServerConfig{
-		Port:     port,
-		DNS:      dns,
-		Scheme:   scheme,
-		Hostname: hostname,
-		CertFile: certfile,
-		KeyFile:  keyfile,
+		Port:         port,
+		DNS:          dns,
+		Scheme:       scheme,
+		Hostname:     hostname,
+		CertFile:     certfile,
+		KeyFile:      keyfile,
+		StatusFormat: statusFormat,
 	}
 }
 
@@ -140,6 +174,41 @@ func NewRethinkDbConfig() *RethinkDbConfig {
 	}
 }
 
+// NewStoreConfig parses EVE_STORE in "type[:path]" format. rethinkdb is used when it's not set.
+func NewStoreConfig() (*StoreConfig, error) {
+	store := strings.TrimSpace(os.Getenv("EVE_STORE"))
+	if store == "" {
+		return &StoreConfig{Type: STORE_RETHINKDB}, nil
+	}
+	parts := strings.SplitN(store, ":", 2)
+	storeConfig := &StoreConfig{Type: parts[0]}
+	if len(parts) == 2 {
+		storeConfig.Path = parts[1]
+	}
+	switch storeConfig.Type {
+	case STORE_RETHINKDB:
+		return storeConfig, nil
+	case STORE_BOLT:
+		if storeConfig.Path == "" {
+			return nil, fmt.Errorf("EVE_STORE %q requires a file path, e.g. bolt:/var/lib/eve/eve.db", store)
+		}
+		return storeConfig, nil
+	}
+	return nil, fmt.Errorf("EVE_STORE %q has unknown store type %q", store, storeConfig.Type)
+}
+
+// NewStateEncryptionConfig reads EVE_STATE_TRANSIT_MOUNT and EVE_STATE_KEY_PREFIX
+func NewStateEncryptionConfig() *StateEncryptionConfig {
+	keyPrefix := os.Getenv("EVE_STATE_KEY_PREFIX")
+	if keyPrefix == "" {
+		keyPrefix = DEFAULT_STATE_KEY_PREFIX
+	}
+	return &StateEncryptionConfig{
+		TransitMount: strings.Trim(os.Getenv("EVE_STATE_TRANSIT_MOUNT"), "/"),
+		KeyPrefix:    keyPrefix,
+	}
+}
+
 func LoadCAFile(caFile string) (*x509.CertPool, error) {
 	pool := x509.NewCertPool()
 
@@ -174,6 +243,53 @@ func NewNatsConfig() *NatsConfig {
 	}
 }
 
+func NewAuthorizationConfig() *AuthorizationConfig {
+	var admins []string
+	for _, v := range strings.Split(os.Getenv("EVE_ADMIN_USERS"), ",") {
+		if admin := strings.TrimSpace(v); admin != "" {
+			admins = append(admins, admin)
+		}
+	}
+	defaultRole := os.Getenv("EVE_DEFAULT_ROLE")
+	if defaultRole == "" {
+		defaultRole = DEFAULT_ROLE
+	}
+	return &AuthorizationConfig{
+		AdminUsers:   admins,
+		RoleBindings: parseRoleBindings(os.Getenv("EVE_ROLE_BINDINGS")),
+		DefaultRole:  defaultRole,
+	}
+}
+
+// parseRoleBindings parses role bindings in "role=subject,subject;role=subject" format,
+// e.g. "viewer=concur;operator=sre,alice"
+func parseRoleBindings(bindings string) map[string][]string {
+	roleBindings := make(map[string][]string)
+	for _, binding := range strings.Split(bindings, ";") {
+		parts := strings.SplitN(binding, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		role := strings.TrimSpace(parts[0])
+		for _, v := range strings.Split(parts[1], ",") {
+			if subject := strings.TrimSpace(v); subject != "" {
+				roleBindings[role] = append(roleBindings[role], subject)
+			}
+		}
+	}
+	return roleBindings
+}
+
+// IsAdminUser reports whether username is listed as eve admin user
+func (authConfig *AuthorizationConfig) IsAdminUser(username string) bool {
+	for _, admin := range authConfig.AdminUsers {
+		if admin == username {
+			return true
+		}
+	}
+	return false
+}
+
 func NewSystemConfig() *SystemConfig {
 	hostname, err := os.Hostname()
 	if err != nil {
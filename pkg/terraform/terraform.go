@@ -10,11 +10,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve/pkg/vault"
-	"github.com/concur/eve/provider/aws"
+	"github.com/concur/eve/provider"
 	"github.com/pborman/uuid"
 )
 
@@ -25,24 +26,17 @@ const (
 	CUSTOM_VAR_FILE        = "varfile"
 	DIR_CONFLICT           = "Directory conflict: "
 	MAX_RETRY              = 15
+	PROVIDER_FILE          = "eve_provider.tf"
 	PERM                   = 0755
-	PROVIDER_TEMPLATE      = `provider "aws" {
-	access_key = "%s"
-	secret_key = "%s"
-	token = "%s"
-  region = "%s"
-  max_retries = 3
-}
-`
 )
 
 type Terraform struct {
-	name          string
-	dir           string
-	remoteState   string
-	modules       []byte
-	varfile       []byte
-	authenticator *aws.Authenticator
+	name        string
+	dir         string
+	remoteState string
+	modules     []byte
+	varfile     []byte
+	credentials provider.Credentials
 }
 
 func NewTerraform(name string, remoteState string, modules []byte, varfile []byte) *Terraform {
@@ -54,13 +48,13 @@ func NewTerraform(name string, remoteState string, modules []byte, varfile []byt
 	}
 }
 
-func NewTerraformWithAuthenticator(name string, remoteState string, modules []byte, varfile []byte, authenticator *aws.Authenticator) *Terraform {
+func NewTerraformWithCredentials(name string, remoteState string, modules []byte, varfile []byte, credentials provider.Credentials) *Terraform {
 	return &Terraform{
-		name:          name,
-		remoteState:   remoteState,
-		modules:       modules,
-		varfile:       varfile,
-		authenticator: authenticator,
+		name:        name,
+		remoteState: remoteState,
+		modules:     modules,
+		varfile:     varfile,
+		credentials: credentials,
 	}
 }
 
@@ -74,6 +68,10 @@ func (tf *Terraform) PlanQuoin() error {
 		return err
 	}
 
+	if err := tf.writeProviderConfig(); err != nil {
+		return err
+	}
+
 	if _, err := tf.runTerraformPlan(); err != nil {
 		return err
 	}
@@ -110,6 +108,10 @@ func (tf *Terraform) ApplyQuoin() error {
 		return err
 	}
 
+	if err := tf.writeProviderConfig(); err != nil {
+		return err
+	}
+
 	log.Println("remote state:", tf.remoteState)
 	if err := tf.runTerraformRemote(); err != nil {
 		return err
@@ -135,6 +137,10 @@ func (tf *Terraform) DeleteQuoin() error {
 		return err
 	}
 
+	if err := tf.writeProviderConfig(); err != nil {
+		return err
+	}
+
 	log.Println("remote state:", tf.remoteState)
 	if err := tf.runTerraformRemote(); err != nil {
 		return err
@@ -255,6 +261,7 @@ func (tf *Terraform) runTerraformPlan() ([]byte, error) {
 	providerEnv, err := tf.addProviderCredEnv(planCommand.Env)
 	if err != nil {
 		log.Println("Command loads env with error:", err)
+		return nil, err
 	}
 	planCommand.Env = providerEnv
 	if err := planCommand.Start(); err != nil {
@@ -358,6 +365,7 @@ func (tf *Terraform) runTerraform(action string) error {
 	providerEnv, err := tf.addProviderCredEnv(planCommand.Env)
 	if err != nil {
 		log.Println("Command loads env with error:", err)
+		return err
 	}
 	planCommand.Env = providerEnv
 	if err := planCommand.Start(); err != nil {
@@ -390,29 +398,59 @@ func (tf *Terraform) writeVarFile() error {
 	return nil
 }
 
-func (tf *Terraform) addProviderCredEnv(env []string) ([]string, error) {
-	c, err := tf.authenticator.Authenticate()
+// writeProviderConfig writes provider block of credentials unless the quoin declares the provider itself
+func (tf *Terraform) writeProviderConfig() error {
+	if tf.credentials == nil {
+		return nil
+	}
+	config := tf.credentials.Config()
+	if config == "" {
+		return nil
+	}
+	declared, err := declaresProvider(tf.dir, tf.credentials.Name())
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if declared {
+		log.Printf("Quoin declares provider %s, skip %s.", tf.credentials.Name(), PROVIDER_FILE)
+		return nil
+	}
+
+	log.Println("Create provider file.")
+	return ioutil.WriteFile(filepath.Join(tf.dir, PROVIDER_FILE), []byte(config), PERM)
+}
+
+// declaresProvider checks whether terraform files in dir contain a provider block with given name
+func declaresProvider(dir string, name string) (bool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return false, err
 	}
-	awsKey := "AWS_ACCESS_KEY_ID"
-	awsSecret := "AWS_SECRET_ACCESS_KEY"
-	awsToken := "AWS_SESSION_TOKEN"
+	pattern := regexp.MustCompile(fmt.Sprintf(`(?m)^\s*provider\s+"?%s"?\s*\{`, regexp.QuoteMeta(name)))
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		if pattern.Match(content) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
+func (tf *Terraform) addProviderCredEnv(env []string) ([]string, error) {
 	if len(env) == 0 {
 		env = os.Environ()
 	}
 
-	env = append(env,
-		fmt.Sprintf("%s=%s", awsKey, c.AccessKeyId),
-		fmt.Sprintf("%s=%s", awsSecret, c.SecretAccessKey),
-	)
-
-	if len(c.SessionToken) > 0 {
-		env = append(env,
-			fmt.Sprintf("%s=%s", awsToken, c.SessionToken),
-		)
+	if tf.credentials == nil {
+		return env, nil
 	}
 
-	return env, nil
+	providerEnv, err := tf.credentials.Env()
+	if err != nil {
+		return nil, err
+	}
+	return append(env, providerEnv...), nil
 }
@@ -0,0 +1,55 @@
+package terraform
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeCredentials struct{}
+
+func (fakeCredentials) Name() string           { return "aws" }
+func (fakeCredentials) Env() ([]string, error) { return []string{"AWS_ACCESS_KEY_ID=key"}, nil }
+func (fakeCredentials) Config() string         { return "provider \"aws\" {}\n" }
+
+func TestWriteProviderConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tf := &Terraform{dir: dir, credentials: fakeCredentials{}}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"aws_vpc\" \"main\" {}\n"), PERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.writeProviderConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, PROVIDER_FILE)); err != nil {
+		t.Errorf("Provider file should be written: %v", err)
+	}
+
+	os.Remove(filepath.Join(dir, PROVIDER_FILE))
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte("provider \"aws\" {\n  region = \"us-west-2\"\n}\n"), PERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := tf.writeProviderConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, PROVIDER_FILE)); !os.IsNotExist(err) {
+		t.Errorf("Provider file should not be written when quoin declares the provider")
+	}
+}
+
+func TestAddProviderCredEnv(t *testing.T) {
+	tf := &Terraform{credentials: fakeCredentials{}}
+	env, err := tf.addProviderCredEnv([]string{"PATH=/bin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env) != 2 || env[1] != "AWS_ACCESS_KEY_ID=key" {
+		t.Errorf("Unexpected env %v", env)
+	}
+}
@@ -0,0 +1,80 @@
+package vault
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// TransitEncrypt encrypts plaintext with the named key of transit backend at mount. The key is created on first use
+func TransitEncrypt(mount string, key string, plaintext []byte) (string, error) {
+	secret, err := writeTransit(fmt.Sprintf("%s/encrypt/%s", mount, key), map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	})
+	if err != nil {
+		return "", err
+	}
+	ciphertext, ok := secret.Data["ciphertext"].(string)
+	if !ok {
+		return "", fmt.Errorf("No ciphertext returned by %s/encrypt/%s", mount, key)
+	}
+	return ciphertext, nil
+}
+
+// TransitDecrypt decrypts ciphertext with the named key of transit backend at mount
+func TransitDecrypt(mount string, key string, ciphertext string) ([]byte, error) {
+	secret, err := writeTransit(fmt.Sprintf("%s/decrypt/%s", mount, key), map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	plaintext, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("No plaintext returned by %s/decrypt/%s", mount, key)
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+// TransitRewrap re-encrypts ciphertext with the latest version of the named key without exposing plaintext
+func TransitRewrap(mount string, key string, ciphertext string) (string, error) {
+	secret, err := writeTransit(fmt.Sprintf("%s/rewrap/%s", mount, key), map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if err != nil {
+		return "", err
+	}
+	rewrapped, ok := secret.Data["ciphertext"].(string)
+	if !ok {
+		return "", fmt.Errorf("No ciphertext returned by %s/rewrap/%s", mount, key)
+	}
+	return rewrapped, nil
+}
+
+// TransitRotate creates a new version of the named key. Earlier versions still decrypt until they're rewrapped
+func TransitRotate(mount string, key string) error {
+	_, err := WriteLogicalData(fmt.Sprintf("%s/keys/%s/rotate", mount, key), nil)
+	return err
+}
+
+func writeTransit(path string, data map[string]interface{}) (*api.Secret, error) {
+	config := api.DefaultConfig()
+	if err := config.ReadEnvironment(); err != nil {
+		return nil, err
+	}
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("No value returned by %s", path)
+	}
+	return secret, nil
+}
@@ -0,0 +1,35 @@
+package vault
+
+import (
+	"os"
+	"testing"
+)
+
+// TestTransit runs against the dev vault started by script/dev, e.g.
+// VAULT_ADDR=http://localhost:8200 VAULT_TOKEN=<root token> EVE_STATE_TRANSIT_MOUNT=transit go test ./pkg/vault
+func TestTransit(t *testing.T) {
+	mount := os.Getenv("EVE_STATE_TRANSIT_MOUNT")
+	if os.Getenv("VAULT_ADDR") == "" || mount == "" {
+		t.Skip("VAULT_ADDR and EVE_STATE_TRANSIT_MOUNT are not set")
+	}
+	key := "eve-state-test"
+
+	ciphertext, err := TransitEncrypt(mount, key, []byte(`{"serial":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := TransitRotate(mount, key); err != nil {
+		t.Fatal(err)
+	}
+	rewrapped, err := TransitRewrap(mount, key, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewrapped == ciphertext {
+		t.Errorf("Rewrap should encrypt with rotated key, got %s", rewrapped)
+	}
+	plaintext, err := TransitDecrypt(mount, key, rewrapped)
+	if err != nil || string(plaintext) != `{"serial":1}` {
+		t.Errorf("Decrypt should return plaintext, got %s, %v", plaintext, err)
+	}
+}
@@ -1,5 +1,9 @@
 package aws
 
+import (
+	"github.com/concur/eve"
+)
+
 // AuthType represents an integer value for
 // AuthType constants
 type AuthType int
@@ -17,4 +21,7 @@ type Account struct {
 	Regions  []string
 	AuthType AuthType
 	Meta     map[string]string
+
+	// Optional account level authorization. Account inherits provider's authorization when it's nil
+	Authorization *eve.Authorization
 }
@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/pkg/vault"
+	"github.com/concur/eve/provider"
+)
+
+const (
+	// META_KEY is the vault key of AWS provider's meta data, e.g. default role
+	META_KEY             = "secret/quoin/providers/aws/meta"
+	DEFAULT_SESSION_NAME = "quoin"
+	TERRAFORM_PROVIDER   = "aws"
+	PROVIDER_TEMPLATE    = `provider "aws" {
+  region = "%s"
+  max_retries = 3
+}
+`
+)
+
+// CredentialsAuthenticator authenticates against AWS and returns static credentials
+type CredentialsAuthenticator interface {
+	Authenticate() (*Credentials, error)
+}
+
+// TerraformCredentials provides terraform with AWS credentials of an account
+// Implements provider.Credentials
+type TerraformCredentials struct {
+	authenticator CredentialsAuthenticator
+	region        string
+}
+
+// NewTerraformCredentials creates terraform credentials from an authenticator
+func NewTerraformCredentials(authenticator CredentialsAuthenticator, region string) *TerraformCredentials {
+	return &TerraformCredentials{
+		authenticator: authenticator,
+		region:        region,
+	}
+}
+
+// Name returns terraform provider name
+func (c *TerraformCredentials) Name() string {
+	return TERRAFORM_PROVIDER
+}
+
+// Env authenticates against AWS and returns credentials as terraform environment variables
+func (c *TerraformCredentials) Env() ([]string, error) {
+	creds, err := c.authenticator.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	env := []string{
+		fmt.Sprintf("AWS_ACCESS_KEY_ID=%s", creds.AccessKeyId),
+		fmt.Sprintf("AWS_SECRET_ACCESS_KEY=%s", creds.SecretAccessKey),
+	}
+	if len(creds.SessionToken) > 0 {
+		env = append(env, fmt.Sprintf("AWS_SESSION_TOKEN=%s", creds.SessionToken))
+	}
+	if len(c.region) > 0 {
+		env = append(env, fmt.Sprintf("AWS_DEFAULT_REGION=%s", c.region))
+	}
+	return env, nil
+}
+
+// Config returns terraform AWS provider block
+func (c *TerraformCredentials) Config() string {
+	if len(c.region) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(PROVIDER_TEMPLATE, c.region)
+}
+
+// FindAccount returns provider account with given name in AWS provider schema
+func FindAccount(schema eve.Schema, name string) (*provider.Account, error) {
+	accounts, err := decodeAccounts(schema)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account == nil || account.Name != name {
+			continue
+		}
+		return &provider.Account{
+			Name:          account.Name,
+			Regions:       account.Regions,
+			Roles:         account.Roles,
+			Authorization: account.Authorization,
+			Detail:        account,
+		}, nil
+	}
+	return nil, nil
+}
+
+// NewCredentials creates terraform credentials for an AWS account
+func NewCredentials(p *eve.Provider, account *provider.Account, options provider.Options) (provider.Credentials, error) {
+	awsAccount, ok := account.Detail.(*Account)
+	if !ok {
+		return nil, fmt.Errorf("Account %s of provider %s is not an AWS account", account.Name, p.Name)
+	}
+
+	role := options.Role
+	if role == "" {
+		defaultRole, err := getDefaultRole()
+		if err != nil {
+			return nil, err
+		}
+		role = defaultRole
+	}
+
+	sessionName := options.SessionName
+	if sessionName == "" {
+		sessionName = DEFAULT_SESSION_NAME
+	}
+
+	region := options.Region
+	if region == "" && len(awsAccount.Regions) > 0 {
+		region = awsAccount.Regions[0]
+	}
+
+	return NewTerraformCredentials(NewAuthenticator(awsAccount, role, sessionName), region), nil
+}
+
+// getDefaultRole reads the default role to assume from vault
+func getDefaultRole() (string, error) {
+	data, err := vault.GetLogicalData(META_KEY)
+	if err != nil {
+		return "", err
+	}
+	role, ok := data["role"].(string)
+	if !ok || role == "" {
+		return "", fmt.Errorf("Default AWS role is missing in %s", META_KEY)
+	}
+	return role, nil
+}
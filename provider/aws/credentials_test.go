@@ -0,0 +1,104 @@
+package aws_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/provider"
+	"github.com/concur/eve/provider/aws"
+)
+
+type fakeAuthenticator struct {
+	credentials *aws.Credentials
+	err         error
+}
+
+func (f fakeAuthenticator) Authenticate() (*aws.Credentials, error) {
+	return f.credentials, f.err
+}
+
+func TestTerraformCredentials_Env(t *testing.T) {
+	creds := aws.NewTerraformCredentials(fakeAuthenticator{
+		credentials: &aws.Credentials{AccessKeyId: "key", SecretAccessKey: "secret", SessionToken: "token"},
+	}, "us-west-2")
+
+	env, err := creds.Env()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{
+		"AWS_ACCESS_KEY_ID=key",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"AWS_SESSION_TOKEN=token",
+		"AWS_DEFAULT_REGION=us-west-2",
+	}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected env %v, got %v", expected, env)
+	}
+	if config := creds.Config(); !strings.Contains(config, `region = "us-west-2"`) || strings.Contains(config, "secret") {
+		t.Errorf("Unexpected provider config: %s", config)
+	}
+}
+
+func TestTerraformCredentials_EnvError(t *testing.T) {
+	creds := aws.NewTerraformCredentials(fakeAuthenticator{err: errors.New("denied")}, "us-west-2")
+	if _, err := creds.Env(); err == nil {
+		t.Errorf("Authentication error should be returned")
+	}
+}
+
+func TestNewCredentials(t *testing.T) {
+	p := &eve.Provider{
+		Name: "aws",
+		Schema: eve.Schema{
+			Type: aws.SCHEMA_TYPE,
+			Data: []interface{}{validAccount()},
+		},
+	}
+
+	creds, err := provider.NewCredentials(p, "dev", provider.Options{Role: "eve-deployer"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds.Name() != aws.TERRAFORM_PROVIDER {
+		t.Errorf("Unexpected terraform provider %s", creds.Name())
+	}
+	if config := creds.Config(); !strings.Contains(config, `region = "us-west-2"`) {
+		t.Errorf("Account's first region should be used by default: %s", config)
+	}
+
+	if _, err := provider.NewCredentials(p, "prod", provider.Options{Role: "eve-deployer"}); err == nil {
+		t.Errorf("Unknown account should be rejected")
+	}
+}
+
+func TestAccount_CheckUsage(t *testing.T) {
+	p := &eve.Provider{
+		Name: "aws",
+		Schema: eve.Schema{
+			Type: aws.SCHEMA_TYPE,
+			Data: []interface{}{validAccount()},
+		},
+	}
+	account, err := provider.FindAccount(p, "dev")
+	if err != nil || account == nil {
+		t.Fatalf("Account dev should be found: %v", err)
+	}
+
+	if err := account.CheckUsage("us-west-2", "eve-deployer"); err != nil {
+		t.Errorf("Allowed region and role should be accepted: %v", err)
+	}
+	if err := account.CheckUsage("", ""); err != nil {
+		t.Errorf("Account defaults should be accepted: %v", err)
+	}
+	errs, ok := account.CheckUsage("eu-west-1", "admin").(provider.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("Expected region and role errors, got %v", errs)
+	}
+	if errs[0].Field != "variables.region" || errs[1].Field != "role" {
+		t.Errorf("Unexpected invalid fields %v", errs)
+	}
+}
@@ -1,12 +1,10 @@
 package aws
 
 import (
-	"reflect"
+	"fmt"
 	"sort"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
-	"github.com/mitchellh/mapstructure"
 )
 
 // accountList Implements the sort interface to sort an array of
@@ -24,12 +22,17 @@ type Provider struct {
 }
 
 // NewProvider returns an instance of an AWS provider
-func NewProvider(provider *eve.Provider) *Provider {
+func NewProvider(provider *eve.Provider) (*Provider, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("NewProvider: provider is missing")
+	}
 	p := &Provider{
 		provider: provider,
 	}
-	p.loadAccounts()
-	return p
+	if err := p.loadAccounts(); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
 // GetAccountNames returns an array of account names
@@ -56,16 +59,16 @@ func (p *Provider) GetAccount(name string) *Account {
 }
 
 // loadAccounts parses the provider schema to hydrate an array of accounts
-func (p *Provider) loadAccounts() {
-	var accounts []*Account
-	if p.provider.Schema.Type == reflect.TypeOf(accounts).String() {
-		err := mapstructure.Decode(p.provider.Schema.Data, &accounts)
+func (p *Provider) loadAccounts() error {
+	switch p.provider.Schema.Type {
+	case SCHEMA_TYPE, LEGACY_SCHEMA_TYPE:
+		accounts, err := decodeAccounts(p.provider.Schema)
 		if err != nil {
-			log.Fatalf("loadAccounts: %s", err)
+			return fmt.Errorf("loadAccounts: %s", err)
 		}
-	} else {
-		accounts = make([]*Account, 0, 0)
+		p.accounts = accounts
+	default:
+		p.accounts = make([]*Account, 0, 0)
 	}
-
-	p.accounts = accounts
+	return nil
 }
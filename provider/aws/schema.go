@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/provider"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// SCHEMA_TYPE is the provider type of AWS providers
+	SCHEMA_TYPE = "aws"
+	// LEGACY_SCHEMA_TYPE is the provider type used before provider type registry
+	LEGACY_SCHEMA_TYPE = "[]*aws.Account"
+
+	maxAccountId = 999999999999
+)
+
+// Regions are the AWS regions which can be used by accounts
+var Regions = map[string]bool{
+	"us-east-1":      true,
+	"us-east-2":      true,
+	"us-west-1":      true,
+	"us-west-2":      true,
+	"us-gov-east-1":  true,
+	"us-gov-west-1":  true,
+	"ca-central-1":   true,
+	"sa-east-1":      true,
+	"eu-central-1":   true,
+	"eu-north-1":     true,
+	"eu-south-1":     true,
+	"eu-west-1":      true,
+	"eu-west-2":      true,
+	"eu-west-3":      true,
+	"ap-east-1":      true,
+	"ap-south-1":     true,
+	"ap-northeast-1": true,
+	"ap-northeast-2": true,
+	"ap-northeast-3": true,
+	"ap-southeast-1": true,
+	"ap-southeast-2": true,
+	"me-south-1":     true,
+	"af-south-1":     true,
+	"cn-north-1":     true,
+	"cn-northwest-1": true,
+}
+
+func init() {
+	t := provider.Type{
+		Validate:    ValidateSchema,
+		FindAccount: FindAccount,
+		Credentials: NewCredentials,
+	}
+	provider.Register(SCHEMA_TYPE, t)
+	provider.Register(LEGACY_SCHEMA_TYPE, t)
+}
+
+// decodeAccounts decodes provider schema data into accounts
+func decodeAccounts(schema eve.Schema) ([]*Account, error) {
+	var accounts []*Account
+	if err := mapstructure.Decode(schema.Data, &accounts); err != nil {
+		return nil, provider.ValidationErrors{{Field: "schema.data", Message: err.Error()}}
+	}
+	return accounts, nil
+}
+
+// ValidateSchema validates accounts in AWS provider schema
+func ValidateSchema(schema eve.Schema) error {
+	accounts, err := decodeAccounts(schema)
+	if err != nil {
+		return err
+	}
+
+	var errs provider.ValidationErrors
+	if len(accounts) == 0 {
+		errs = append(errs, provider.ValidationError{Field: "schema.data", Message: "at least one account is required"})
+	}
+
+	names := make(map[string]bool)
+	for i, account := range accounts {
+		field := fmt.Sprintf("schema.data[%d]", i)
+		if account == nil {
+			errs = append(errs, provider.ValidationError{Field: field, Message: "account is empty"})
+			continue
+		}
+		if account.Name == "" {
+			errs = append(errs, provider.ValidationError{Field: field + ".name", Message: "account name is missing"})
+		} else if names[account.Name] {
+			errs = append(errs, provider.ValidationError{Field: field + ".name", Message: fmt.Sprintf("account name %s is duplicated", account.Name)})
+		}
+		names[account.Name] = true
+
+		if account.Id <= 0 || account.Id > maxAccountId {
+			errs = append(errs, provider.ValidationError{Field: field + ".id", Message: fmt.Sprintf("%d is not a valid 12-digit AWS account id", account.Id)})
+		}
+
+		if len(account.Regions) == 0 {
+			errs = append(errs, provider.ValidationError{Field: field + ".regions", Message: "at least one region is required"})
+		}
+		for j, region := range account.Regions {
+			if !Regions[region] {
+				errs = append(errs, provider.ValidationError{Field: fmt.Sprintf("%s.regions[%d]", field, j), Message: fmt.Sprintf("unknown AWS region %q", region)})
+			}
+		}
+
+		if len(account.Roles) == 0 {
+			errs = append(errs, provider.ValidationError{Field: field + ".roles", Message: "at least one role is required"})
+		}
+		for j, role := range account.Roles {
+			if role == "" {
+				errs = append(errs, provider.ValidationError{Field: fmt.Sprintf("%s.roles[%d]", field, j), Message: "role is empty"})
+			}
+		}
+
+		switch account.AuthType {
+		case IAM, AssumeRole:
+		default:
+			errs = append(errs, provider.ValidationError{Field: field + ".authType", Message: fmt.Sprintf("unsupported auth type %d", account.AuthType)})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package aws_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/provider"
+	"github.com/concur/eve/provider/aws"
+)
+
+func validAccount() map[string]interface{} {
+	return map[string]interface{}{
+		"Name":     "dev",
+		"Id":       float64(123456789012),
+		"Roles":    []interface{}{"eve-deployer"},
+		"Regions":  []interface{}{"us-west-2"},
+		"AuthType": float64(aws.AssumeRole),
+	}
+}
+
+func TestValidate_ValidProvider(t *testing.T) {
+	p := &eve.Provider{
+		Name: "aws",
+		Schema: eve.Schema{
+			Type: aws.SCHEMA_TYPE,
+			Data: []interface{}{validAccount()},
+		},
+	}
+	if err := provider.Validate(p); err != nil {
+		t.Errorf("Provider should be valid: %v", err)
+	}
+
+	p.Schema.Type = aws.LEGACY_SCHEMA_TYPE
+	if err := provider.Validate(p); err != nil {
+		t.Errorf("Provider with legacy schema type should be valid: %v", err)
+	}
+}
+
+func TestValidate_InvalidAccount(t *testing.T) {
+	account := validAccount()
+	account["Id"] = float64(0)
+	account["Regions"] = []interface{}{"mars-north-1"}
+	account["Roles"] = []interface{}{}
+	account["AuthType"] = float64(7)
+	p := &eve.Provider{
+		Name: "aws",
+		Schema: eve.Schema{
+			Type: aws.SCHEMA_TYPE,
+			Data: []interface{}{account},
+		},
+	}
+
+	err := provider.Validate(p)
+	errs, ok := err.(provider.ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate should return ValidationErrors, got: %#v", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, field := range []string{"schema.data[0].id", "schema.data[0].regions[0]", "schema.data[0].roles", "schema.data[0].authType"} {
+		if !fields[field] {
+			t.Errorf("Validate should report invalid field %s. Errors: %v", field, errs)
+		}
+	}
+}
+
+func TestValidate_UnknownType(t *testing.T) {
+	p := &eve.Provider{
+		Name:   "gcp",
+		Schema: eve.Schema{Type: "unknown"},
+	}
+	err := provider.Validate(p)
+	if err == nil || !strings.Contains(err.Error(), "schema.type") {
+		t.Errorf("Validate should reject unknown provider type, got: %v", err)
+	}
+}
+
+func TestNewProvider_BadData(t *testing.T) {
+	p := &eve.Provider{
+		Name:   "aws",
+		Schema: eve.Schema{Type: aws.SCHEMA_TYPE, Data: "not accounts"},
+	}
+	if _, err := aws.NewProvider(p); err == nil {
+		t.Errorf("NewProvider should return error on bad schema data")
+	}
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concur/eve"
+)
+
+// Account is the provider type independent view of an account in provider's schema
+type Account struct {
+	Name    string
+	Regions []string
+	Roles   []string
+
+	// Optional account level authorization. Account inherits provider's authorization when it's nil
+	Authorization *eve.Authorization
+
+	// Provider type specific account, e.g. *aws.Account
+	Detail interface{}
+}
+
+// Options are infrastructure level settings used to build credentials
+type Options struct {
+	Region      string // infrastructure's region
+	Role        string // role to assume in account
+	SessionName string // session name used by provider's audit trail
+}
+
+// Credentials provides terraform with the access to a cloud provider's account
+type Credentials interface {
+	// Name returns terraform provider name, e.g. "aws"
+	Name() string
+
+	// Env returns environment variables carrying credentials for terraform process
+	Env() ([]string, error)
+
+	// Config returns terraform provider configuration block without any secret
+	Config() string
+}
+
+// CheckUsage validates that region and role are in account's allowlists.
+// Empty region or role means account's default and is always allowed.
+func (a *Account) CheckUsage(region, role string) error {
+	var errs ValidationErrors
+	if region != "" && len(a.Regions) > 0 && !contains(a.Regions, region) {
+		errs = append(errs, ValidationError{
+			Field:   "variables.region",
+			Message: fmt.Sprintf("region %s is not allowed in account %s, allowed regions are: %s", region, a.Name, strings.Join(a.Regions, ", ")),
+		})
+	}
+	if role != "" && !contains(a.Roles, role) {
+		errs = append(errs, ValidationError{
+			Field:   "role",
+			Message: fmt.Sprintf("role %s is not allowed in account %s, allowed roles are: %s", role, a.Name, strings.Join(a.Roles, ", ")),
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
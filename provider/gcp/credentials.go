@@ -0,0 +1,105 @@
+package gcp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/pkg/vault"
+	"github.com/concur/eve/provider"
+)
+
+const (
+	// CREDENTIALS_KEY_PREFIX is the default vault path of project's service account credentials
+	CREDENTIALS_KEY_PREFIX = "secret/quoin/providers/gcp/"
+	TERRAFORM_PROVIDER     = "google"
+	PROVIDER_TEMPLATE      = `provider "google" {
+  project = "%s"
+  region = "%s"
+}
+`
+)
+
+// SecretReader reads secret data stored at vault path
+type SecretReader func(path string) (map[string]interface{}, error)
+
+// TerraformCredentials provides terraform with static service account credentials stored in vault
+// Implements provider.Credentials
+type TerraformCredentials struct {
+	read      SecretReader
+	key       string
+	projectId string
+	region    string
+}
+
+// NewTerraformCredentials creates terraform credentials which reads service account key at vault path
+func NewTerraformCredentials(read SecretReader, key, projectId, region string) *TerraformCredentials {
+	return &TerraformCredentials{
+		read:      read,
+		key:       key,
+		projectId: projectId,
+		region:    region,
+	}
+}
+
+// Name returns terraform provider name
+func (c *TerraformCredentials) Name() string {
+	return TERRAFORM_PROVIDER
+}
+
+// Env reads service account credentials from vault and returns them as terraform environment variables
+func (c *TerraformCredentials) Env() ([]string, error) {
+	data, err := c.read(c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials string
+	switch v := data["credentials"].(type) {
+	case string:
+		credentials = v
+	case map[string]interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		credentials = string(b)
+	}
+	if credentials == "" {
+		return nil, fmt.Errorf("Service account credentials are missing in %s", c.key)
+	}
+
+	env := []string{
+		fmt.Sprintf("GOOGLE_CREDENTIALS=%s", credentials),
+		fmt.Sprintf("GOOGLE_PROJECT=%s", c.projectId),
+	}
+	if len(c.region) > 0 {
+		env = append(env, fmt.Sprintf("GOOGLE_REGION=%s", c.region))
+	}
+	return env, nil
+}
+
+// Config returns terraform google provider block
+func (c *TerraformCredentials) Config() string {
+	return fmt.Sprintf(PROVIDER_TEMPLATE, c.projectId, c.region)
+}
+
+// NewCredentials creates terraform credentials for a GCP project
+func NewCredentials(p *eve.Provider, account *provider.Account, options provider.Options) (provider.Credentials, error) {
+	project, ok := account.Detail.(*Project)
+	if !ok {
+		return nil, fmt.Errorf("Account %s of provider %s is not a GCP project", account.Name, p.Name)
+	}
+
+	key := project.CredentialsKey
+	if key == "" {
+		key = CREDENTIALS_KEY_PREFIX + project.Name
+	}
+
+	region := options.Region
+	if region == "" && len(project.Regions) > 0 {
+		region = project.Regions[0]
+	}
+
+	return NewTerraformCredentials(vault.GetLogicalData, key, project.ProjectId, region), nil
+}
@@ -0,0 +1,90 @@
+package gcp_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/provider"
+	"github.com/concur/eve/provider/gcp"
+)
+
+func validProject() map[string]interface{} {
+	return map[string]interface{}{
+		"Name":      "dev",
+		"ProjectId": "eve-dev-123",
+		"Regions":   []interface{}{"us-central1"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	p := &eve.Provider{
+		Name: "gcp",
+		Schema: eve.Schema{
+			Type: gcp.SCHEMA_TYPE,
+			Data: []interface{}{validProject()},
+		},
+	}
+	if err := provider.Validate(p); err != nil {
+		t.Errorf("Provider should be valid: %v", err)
+	}
+
+	invalid := validProject()
+	invalid["ProjectId"] = "Eve"
+	invalid["Regions"] = []interface{}{"central"}
+	p.Schema.Data = []interface{}{invalid}
+	errs, ok := provider.Validate(p).(provider.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Errorf("Expected project id and region errors, got %v", errs)
+	}
+}
+
+func TestTerraformCredentials_Env(t *testing.T) {
+	read := func(path string) (map[string]interface{}, error) {
+		if path != "secret/gcp/dev" {
+			return nil, errors.New("not found")
+		}
+		return map[string]interface{}{"credentials": `{"type":"service_account"}`}, nil
+	}
+	creds := gcp.NewTerraformCredentials(read, "secret/gcp/dev", "eve-dev-123", "us-central1")
+
+	env, err := creds.Env()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	joined := strings.Join(env, "\n")
+	for _, expected := range []string{`GOOGLE_CREDENTIALS={"type":"service_account"}`, "GOOGLE_PROJECT=eve-dev-123", "GOOGLE_REGION=us-central1"} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("Missing %s in env %v", expected, env)
+		}
+	}
+	if config := creds.Config(); !strings.Contains(config, `project = "eve-dev-123"`) {
+		t.Errorf("Unexpected provider config: %s", config)
+	}
+
+	missing := gcp.NewTerraformCredentials(read, "secret/gcp/prod", "eve-prod-123", "us-central1")
+	if _, err := missing.Env(); err == nil {
+		t.Errorf("Vault error should be returned")
+	}
+}
+
+func TestNewCredentials(t *testing.T) {
+	p := &eve.Provider{
+		Name: "gcp",
+		Schema: eve.Schema{
+			Type: gcp.SCHEMA_TYPE,
+			Data: []interface{}{validProject()},
+		},
+	}
+	creds, err := provider.NewCredentials(p, "dev", provider.Options{Region: "europe-west1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if creds.Name() != gcp.TERRAFORM_PROVIDER {
+		t.Errorf("Unexpected terraform provider %s", creds.Name())
+	}
+	if config := creds.Config(); !strings.Contains(config, `region = "europe-west1"`) {
+		t.Errorf("Infrastructure region should be used: %s", config)
+	}
+}
@@ -0,0 +1,116 @@
+package gcp
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/provider"
+	"github.com/mitchellh/mapstructure"
+)
+
+// SCHEMA_TYPE is the provider type of Google Cloud providers
+const SCHEMA_TYPE = "gcp"
+
+var (
+	projectIdPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	regionPattern    = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]$`)
+)
+
+// Project represents a Google Cloud project which is an account of GCP provider
+type Project struct {
+	Name      string
+	ProjectId string
+	Regions   []string
+	Roles     []string
+
+	// Vault key of the service account credentials, defaults to secret/quoin/providers/gcp/<name>
+	CredentialsKey string
+
+	// Optional project level authorization. Project inherits provider's authorization when it's nil
+	Authorization *eve.Authorization
+}
+
+func init() {
+	provider.Register(SCHEMA_TYPE, provider.Type{
+		Validate:    ValidateSchema,
+		FindAccount: FindAccount,
+		Credentials: NewCredentials,
+	})
+}
+
+// decodeProjects decodes provider schema data into projects
+func decodeProjects(schema eve.Schema) ([]*Project, error) {
+	var projects []*Project
+	if err := mapstructure.Decode(schema.Data, &projects); err != nil {
+		return nil, provider.ValidationErrors{{Field: "schema.data", Message: err.Error()}}
+	}
+	return projects, nil
+}
+
+// ValidateSchema validates projects in GCP provider schema
+func ValidateSchema(schema eve.Schema) error {
+	projects, err := decodeProjects(schema)
+	if err != nil {
+		return err
+	}
+
+	var errs provider.ValidationErrors
+	if len(projects) == 0 {
+		errs = append(errs, provider.ValidationError{Field: "schema.data", Message: "at least one project is required"})
+	}
+
+	names := make(map[string]bool)
+	for i, project := range projects {
+		field := fmt.Sprintf("schema.data[%d]", i)
+		if project == nil {
+			errs = append(errs, provider.ValidationError{Field: field, Message: "project is empty"})
+			continue
+		}
+		if project.Name == "" {
+			errs = append(errs, provider.ValidationError{Field: field + ".name", Message: "project name is missing"})
+		} else if names[project.Name] {
+			errs = append(errs, provider.ValidationError{Field: field + ".name", Message: fmt.Sprintf("project name %s is duplicated", project.Name)})
+		}
+		names[project.Name] = true
+
+		if !projectIdPattern.MatchString(project.ProjectId) {
+			errs = append(errs, provider.ValidationError{Field: field + ".projectId", Message: fmt.Sprintf("%q is not a valid GCP project id", project.ProjectId)})
+		}
+
+		if len(project.Regions) == 0 {
+			errs = append(errs, provider.ValidationError{Field: field + ".regions", Message: "at least one region is required"})
+		}
+		for j, region := range project.Regions {
+			if !regionPattern.MatchString(region) {
+				errs = append(errs, provider.ValidationError{Field: fmt.Sprintf("%s.regions[%d]", field, j), Message: fmt.Sprintf("invalid GCP region %q", region)})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// FindAccount returns provider account with given project name in GCP provider schema
+func FindAccount(schema eve.Schema, name string) (*provider.Account, error) {
+	projects, err := decodeProjects(schema)
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		if project == nil || project.Name != name {
+			continue
+		}
+		return &provider.Account{
+			Name:          project.Name,
+			Regions:       project.Regions,
+			Roles:         project.Roles,
+			Authorization: project.Authorization,
+			Detail:        project,
+		}, nil
+	}
+	return nil, nil
+}
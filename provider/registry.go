@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/concur/eve"
+)
+
+// SchemaValidator validates provider's schema data for a provider type
+type SchemaValidator func(schema eve.Schema) error
+
+// AccountFinder finds the account with given name in provider's schema data
+type AccountFinder func(schema eve.Schema, name string) (*Account, error)
+
+// CredentialsFactory builds terraform credentials for an account of the provider
+type CredentialsFactory func(provider *eve.Provider, account *Account, options Options) (Credentials, error)
+
+// Type is a provider type, e.g. "aws", registered by provider type packages from their init function
+type Type struct {
+	Validate    SchemaValidator
+	FindAccount AccountFinder
+	Credentials CredentialsFactory
+}
+
+var (
+	mu    sync.RWMutex
+	types = make(map[string]Type)
+)
+
+// Register registers a provider type with its schema type name
+func Register(schemaType string, t Type) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := types[schemaType]; ok {
+		panic(fmt.Sprintf("provider: schema type %s is registered twice", schemaType))
+	}
+	types[schemaType] = t
+}
+
+// SchemaTypes returns the registered provider types
+func SchemaTypes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(types))
+	for schemaType := range types {
+		names = append(names, schemaType)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupType(schemaType string) (Type, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := types[schemaType]
+	return t, ok
+}
+
+func unknownTypeError(schemaType string) ValidationError {
+	return ValidationError{
+		Field:   "schema.type",
+		Message: fmt.Sprintf("unknown provider type %q, registered types are: %s", schemaType, strings.Join(SchemaTypes(), ", ")),
+	}
+}
+
+// Validate checks provider's name and validates provider's schema with the registered validator
+func Validate(provider *eve.Provider) error {
+	var errs ValidationErrors
+	if provider.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "provider name is missing"})
+	}
+
+	t, ok := lookupType(provider.Schema.Type)
+	if !ok {
+		return append(errs, unknownTypeError(provider.Schema.Type))
+	}
+
+	if err := t.Validate(provider.Schema); err != nil {
+		if schemaErrs, ok := err.(ValidationErrors); ok {
+			errs = append(errs, schemaErrs...)
+		} else {
+			errs = append(errs, ValidationError{Field: "schema.data", Message: err.Error()})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// FindAccount returns the provider's account with given name, or nil when the account doesn't exist
+func FindAccount(provider *eve.Provider, name string) (*Account, error) {
+	t, ok := lookupType(provider.Schema.Type)
+	if !ok {
+		return nil, ValidationErrors{unknownTypeError(provider.Schema.Type)}
+	}
+	return t.FindAccount(provider.Schema, name)
+}
+
+// NewCredentials builds terraform credentials for the account referenced by provider slug <provider:account>
+func NewCredentials(provider *eve.Provider, accountName string, options Options) (Credentials, error) {
+	t, ok := lookupType(provider.Schema.Type)
+	if !ok {
+		return nil, ValidationErrors{unknownTypeError(provider.Schema.Type)}
+	}
+	account, err := t.FindAccount(provider.Schema, accountName)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, fmt.Errorf("Account %s is not found in provider %s", accountName, provider.Name)
+	}
+	return t.Credentials(provider, account, options)
+}
+
+// ValidationError describes an invalid field of provider
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors collects all of invalid fields found in a provider or its usage
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	return fmt.Sprintf("Invalid request: %s", strings.Join(messages, "; "))
+}
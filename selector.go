@@ -0,0 +1,177 @@
+package eve
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Label selector operators
+const (
+	SELECTOR_EQUALS         = "="
+	SELECTOR_NOT_EQUALS     = "!="
+	SELECTOR_IN             = "in"
+	SELECTOR_NOT_IN         = "notin"
+	SELECTOR_EXISTS         = "exists"
+	SELECTOR_DOES_NOT_EXIST = "!"
+)
+
+var (
+	labelKeyRegexp   = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9_./]{0,61})?[A-Za-z0-9]$`)
+	labelValueRegexp = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9])?$`)
+)
+
+// ValidateLabels checks label keys and values. Keys and values are at most 63 alphanumeric characters,
+// '-', '_' or '.', beginning and ending with an alphanumeric character. Keys may also contain '/'.
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if !labelKeyRegexp.MatchString(key) {
+			return fmt.Errorf("Invalid label key %q", key)
+		}
+		if !labelValueRegexp.MatchString(value) {
+			return fmt.Errorf("Invalid value %q of label %s", value, key)
+		}
+	}
+	return nil
+}
+
+// Requirement is one comma separated term of a label selector, e.g. "team in (a,b)"
+type Requirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// Matches reports whether labels satisfy the requirement
+func (req Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[req.Key]
+	switch req.Operator {
+	case SELECTOR_EQUALS, SELECTOR_IN:
+		return ok && contains(req.Values, value)
+	case SELECTOR_NOT_EQUALS, SELECTOR_NOT_IN:
+		return !ok || !contains(req.Values, value)
+	case SELECTOR_EXISTS:
+		return ok
+	case SELECTOR_DOES_NOT_EXIST:
+		return !ok
+	}
+	return false
+}
+
+func (req Requirement) String() string {
+	switch req.Operator {
+	case SELECTOR_EQUALS, SELECTOR_NOT_EQUALS:
+		return req.Key + req.Operator + req.Values[0]
+	case SELECTOR_IN, SELECTOR_NOT_IN:
+		return fmt.Sprintf("%s %s (%s)", req.Key, req.Operator, strings.Join(req.Values, ","))
+	case SELECTOR_DOES_NOT_EXIST:
+		return "!" + req.Key
+	}
+	return req.Key
+}
+
+// Selector is a Kubernetes style label selector, e.g. "env=prod,team in (a,b),!deprecated".
+// Labels match the selector when they satisfy all of its requirements. Empty selector matches everything.
+type Selector []Requirement
+
+// Matches reports whether labels satisfy all of selector's requirements
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	terms := make([]string, len(s))
+	for i, req := range s {
+		terms[i] = req.String()
+	}
+	return strings.Join(terms, ",")
+}
+
+// ParseSelector parses comma separated requirements in one of the forms
+// "key=value", "key==value", "key!=value", "key in (v1,v2)", "key notin (v1,v2)", "key" and "!key"
+func ParseSelector(selector string) (Selector, error) {
+	var s Selector
+	for _, term := range splitSelector(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		req, err := parseRequirement(term)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid label selector %q: %v", selector, err)
+		}
+		s = append(s, req)
+	}
+	return s, nil
+}
+
+// splitSelector splits selector on commas outside of parentheses
+func splitSelector(selector string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, selector[start:])
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	var req Requirement
+	switch {
+	case strings.HasPrefix(term, "!") && !strings.Contains(term, "="):
+		req = Requirement{Key: strings.TrimSpace(term[1:]), Operator: SELECTOR_DOES_NOT_EXIST}
+	case strings.Contains(term, "!="):
+		parts := strings.SplitN(term, "!=", 2)
+		req = Requirement{Key: strings.TrimSpace(parts[0]), Operator: SELECTOR_NOT_EQUALS, Values: []string{strings.TrimSpace(parts[1])}}
+	case strings.Contains(term, "="):
+		parts := strings.SplitN(strings.Replace(term, "==", "=", 1), "=", 2)
+		req = Requirement{Key: strings.TrimSpace(parts[0]), Operator: SELECTOR_EQUALS, Values: []string{strings.TrimSpace(parts[1])}}
+	case strings.Contains(term, "("):
+		fields := strings.Fields(term[:strings.Index(term, "(")])
+		if len(fields) != 2 || (fields[1] != SELECTOR_IN && fields[1] != SELECTOR_NOT_IN) || !strings.HasSuffix(term, ")") {
+			return req, fmt.Errorf("%q should be in \"key in (v1,v2)\" or \"key notin (v1,v2)\" form", term)
+		}
+		req = Requirement{Key: fields[0], Operator: fields[1]}
+		for _, value := range strings.Split(term[strings.Index(term, "(")+1:len(term)-1], ",") {
+			req.Values = append(req.Values, strings.TrimSpace(value))
+		}
+		sort.Strings(req.Values)
+	default:
+		req = Requirement{Key: term, Operator: SELECTOR_EXISTS}
+	}
+
+	if !labelKeyRegexp.MatchString(req.Key) {
+		return req, fmt.Errorf("invalid label key %q", req.Key)
+	}
+	for _, value := range req.Values {
+		if !labelValueRegexp.MatchString(value) {
+			return req, fmt.Errorf("invalid label value %q", value)
+		}
+	}
+	return req, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
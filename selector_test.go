@@ -0,0 +1,50 @@
+package eve
+
+import "testing"
+
+func TestParseSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "b", "tier": "web"}
+	cases := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env==prod,team in (a, b)", true},
+		{"env!=prod", false},
+		{"team notin (a,b)", false},
+		{"tier", true},
+		{"!deprecated", true},
+		{"!tier", false},
+		{"env=prod,team in (a,c)", false},
+		{"owner!=alice", true},
+	}
+	for _, c := range cases {
+		selector, err := ParseSelector(c.selector)
+		if err != nil {
+			t.Errorf("ParseSelector(%q) returns error: %v", c.selector, err)
+			continue
+		}
+		if selector.Matches(labels) != c.matches {
+			t.Errorf("Selector %q should match %v: %v", c.selector, labels, c.matches)
+		}
+		if reparsed, err := ParseSelector(selector.String()); err != nil || reparsed.String() != selector.String() {
+			t.Errorf("Selector %q should round trip through String(), got %q, %v", c.selector, selector.String(), err)
+		}
+	}
+
+	for _, invalid := range []string{"env in prod", "team in (a,b", "-env=prod", "env=prod value"} {
+		if _, err := ParseSelector(invalid); err == nil {
+			t.Errorf("ParseSelector(%q) should return error", invalid)
+		}
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	if err := ValidateLabels(map[string]string{"env": "prod", "example.com/team": "sre", "empty": ""}); err != nil {
+		t.Errorf("Labels should be valid: %v", err)
+	}
+	if err := ValidateLabels(map[string]string{"env": "prod!"}); err == nil {
+		t.Errorf("Label value with '!' should be invalid")
+	}
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+)
+
+// auditOwnershipTransfer stores the audit record of resource's ownership transfer
+func auditOwnershipTransfer(store eve.AuditStore, resourceType string, name string, actor eve.UserId, from eve.UserId, to eve.UserId) error {
+	record := &eve.AuditRecord{
+		ResourceType: resourceType,
+		ResourceName: name,
+		Action:       eve.AUDIT_TRANSFER_OWNERSHIP,
+		Actor:        actor,
+		Metadata: map[string]string{
+			"from": string(from),
+			"to":   string(to),
+		},
+		Timestamp: time.Now(),
+	}
+
+	if err := store.InsertAuditRecord(record); err != nil {
+		return err
+	}
+	log.Printf("User %s transfers %s %s ownership from %s to %s", actor, resourceType, name, from, to)
+	return nil
+}
+
+// auditStateChange stores the audit record of infrastructure's state changed outside of terraform
+func auditStateChange(store eve.AuditStore, action eve.AuditAction, name string, actor eve.UserId, metadata map[string]string) error {
+	record := &eve.AuditRecord{
+		ResourceType: "infrastructure",
+		ResourceName: name,
+		Action:       action,
+		Actor:        actor,
+		Metadata:     metadata,
+		Timestamp:    time.Now(),
+	}
+
+	if err := store.InsertAuditRecord(record); err != nil {
+		return err
+	}
+	log.Printf("User %s does %s on infrastructure %s", actor, action, name)
+	return nil
+}
@@ -0,0 +1,20 @@
+package boltdb
+
+import (
+	"github.com/concur/eve"
+	"github.com/pborman/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (s *Store) InsertAuditRecord(record *eve.AuditRecord) error {
+	return s.update(func(tx *bolt.Tx) error {
+		id := uuid.NewRandom().String()
+		stored := *record
+		stored.Id = id
+		if err := put(tx, AUDIT_BUCKET, id, &stored); err != nil {
+			return err
+		}
+		record.Id = id
+		return nil
+	})
+}
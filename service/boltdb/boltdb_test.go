@@ -0,0 +1,137 @@
+package boltdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/concur/eve"
+)
+
+func newTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "eve-bolt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(filepath.Join(dir, "eve.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return store, func() { os.RemoveAll(dir) }
+}
+
+func TestStore_infrastructureLifecycle(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	quoin := &eve.Quoin{Name: "vpc", ArchiveUri: "/quoin/vpc/upload"}
+	if err := store.InsertQuoin(quoin); err != nil {
+		t.Fatal(err)
+	}
+	if quoin.Id != nameKey("vpc") {
+		t.Errorf("Quoin id should be derived from its name, got %s", quoin.Id)
+	}
+	if err := store.InsertQuoin(&eve.Quoin{Name: "vpc"}); err == nil {
+		t.Errorf("InsertQuoin should reject duplicated name")
+	}
+	archive := &eve.QuoinArchive{QuoinName: "vpc", Modules: []byte("archive")}
+	if err := store.InsertQuoinArchive(archive); err != nil {
+		t.Fatal(err)
+	}
+	quoin, err := store.GetQuoinByName("vpc")
+	if err != nil || quoin.Status != eve.VALIDATED || quoin.ArchiveUri != "/quoin/vpc/upload/"+archive.Id {
+		t.Errorf("InsertQuoinArchive should validate quoin and point it to archive, got %#v, %v", quoin, err)
+	}
+
+	infra := &eve.Infrastructure{Name: "dev-vpc", Quoin: quoin, ProviderSlug: "aws:dev", Status: eve.VALIDATED}
+	if err := store.InsertInfrastructure(infra); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateInfrastructureStatus("dev-vpc", eve.DEPLOYED); err == nil {
+		t.Errorf("UpdateInfrastructureStatus should reject validated -> deployed")
+	} else if _, ok := err.(*eve.TransitionError); !ok {
+		t.Errorf("UpdateInfrastructureStatus should return TransitionError, got %v", err)
+	}
+	if err := store.UpdateInfrastructureStatus("dev-vpc", eve.RUNNING); err != nil {
+		t.Fatal(err)
+	}
+
+	// records outlive the store handle
+	reopened, err := NewStore(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := reopened.GetInfrastructureByName("dev-vpc")
+	if err != nil || stored == nil || stored.Status != eve.RUNNING {
+		t.Errorf("GetInfrastructureByName should return running infrastructure, got %#v, %v", stored, err)
+	}
+	if infras, err := reopened.GetInfrastructuresByQuoin("vpc"); err != nil || len(infras) != 1 {
+		t.Errorf("GetInfrastructuresByQuoin should return dev-vpc, got %#v, %v", infras, err)
+	}
+	if count, err := reopened.CountInfrastructuresByProvider("aws"); err != nil || count != 1 {
+		t.Errorf("CountInfrastructuresByProvider should count dev-vpc, got %d, %v", count, err)
+	}
+	if missing, err := reopened.GetInfrastructureByName("missing"); err != nil || missing != nil {
+		t.Errorf("GetInfrastructureByName should return nil for missing infrastructure, got %#v, %v", missing, err)
+	}
+}
+
+func TestStore_providerAccess(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.InsertProvider(&eve.Provider{Name: "aws"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateProviderAccess("aws", eve.Group("sre"), eve.POLICY_EXECUTE); err != nil {
+		t.Fatal(err)
+	}
+	if provider, _ := store.GetProviderByName("aws"); provider.Authorization.GroupAccess[eve.Group("sre")] != eve.POLICY_EXECUTE {
+		t.Errorf("UpdateProviderAccess should grant access, got %#v", provider.Authorization)
+	}
+	if err := store.DeleteProviderAccess("aws", eve.Group("sre")); err != nil {
+		t.Fatal(err)
+	}
+	if provider, _ := store.GetProviderByName("aws"); len(provider.Authorization.GroupAccess) != 0 {
+		t.Errorf("DeleteProviderAccess should revoke access, got %#v", provider.Authorization)
+	}
+	if err := store.UpdateProviderAccess("missing", eve.Group("sre"), eve.POLICY_EXECUTE); err != nil {
+		t.Errorf("UpdateProviderAccess should skip missing provider, got %v", err)
+	}
+}
+
+func TestStore_conditionalStateVersion(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.InsertInfrastructure(&eve.Infrastructure{Name: "dev", Status: eve.DEPLOYED}); err != nil {
+		t.Fatal(err)
+	}
+	condition := &eve.StateCondition{RejectStale: true}
+	if err := store.InsertStateVersion(eve.NewStateVersion("dev", map[string]interface{}{"serial": 2.0, "lineage": "abc"}, "alice"), condition); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.InsertStateVersion(eve.NewStateVersion("dev", map[string]interface{}{"serial": 1.0, "lineage": "abc"}, "alice"), condition).(*eve.StaleStateError); !ok {
+		t.Errorf("InsertStateVersion should reject lower serial")
+	}
+	if _, err := store.InsertStateLock(&eve.StateLock{ID: "lock-1", InfrastructureName: "dev"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.InsertStateVersion(eve.NewStateVersion("dev", map[string]interface{}{"serial": 3.0, "lineage": "abc"}, "alice"), condition).(*eve.StateLockError); !ok {
+		t.Errorf("InsertStateVersion should reject state locked by another lock")
+	}
+	if _, ok := store.UpdateInfrastructureState("dev", nil, &eve.StateCondition{LockId: "lock-2"}).(*eve.StateLockError); !ok {
+		t.Errorf("UpdateInfrastructureState should reject state locked by another lock")
+	}
+	if err := store.InsertStateVersion(eve.NewStateVersion("missing", nil, "alice"), nil); err == nil {
+		t.Errorf("InsertStateVersion should reject missing infrastructure")
+	}
+	if versions, _ := store.GetStateVersionsByInfrastructure("dev"); len(versions) != 1 || versions[0].Serial != 2 {
+		t.Errorf("Rejected state should not be stored as version, got %#v", versions)
+	}
+	if infra, _ := store.GetInfrastructureByName("dev"); infra.State["serial"] != 2.0 {
+		t.Errorf("Rejected state should not replace current state, got %v", infra.State)
+	}
+}
@@ -0,0 +1,110 @@
+package boltdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/concur/eve"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	PROVIDER_BUCKET      = "provider"
+	QUOIN_BUCKET         = "quoin"
+	QUOIN_ARCHIVE_BUCKET = "quoinArchive"
+	INFRA_BUCKET         = "infrastructure"
+	AUDIT_BUCKET         = "audit"
+	STATE_VERSION_BUCKET = "stateVersion"
+	STATE_LOCK_BUCKET    = "stateLock"
+
+	// LOCK_TIMEOUT bounds the wait for the file lock held by another eve process on the same node
+	LOCK_TIMEOUT = 10 * time.Second
+)
+
+var buckets = []string{PROVIDER_BUCKET, QUOIN_BUCKET, QUOIN_ARCHIVE_BUCKET, INFRA_BUCKET, AUDIT_BUCKET, STATE_VERSION_BUCKET, STATE_LOCK_BUCKET}
+
+// Store is an eve.Store persisted in a single bolt file. The file is opened for every operation,
+// so eve server and agents running on the same node can share it.
+type Store struct {
+	Path string
+}
+
+var _ eve.Store = (*Store)(nil)
+
+// NewStore creates the bolt file at path with all of eve's buckets when it doesn't exist
+func NewStore(path string) (*Store, error) {
+	s := &Store{Path: path}
+	if err := s.update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) open() (*bolt.DB, error) {
+	return bolt.Open(s.Path, 0600, &bolt.Options{Timeout: LOCK_TIMEOUT})
+}
+
+// view runs fn in a read-only transaction
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// update runs fn in a read-write transaction. Everything in fn is applied atomically.
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// nameKey returns record's key from its unique name like rethinkdb's r.UUID(name)
+func nameKey(name string) string {
+	return eve.NameId(name)
+}
+
+// get decodes record at key into v, and returns false when the record doesn't exist
+func get(tx *bolt.Tx, bucket string, key string, v interface{}) (bool, error) {
+	data := tx.Bucket([]byte(bucket)).Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+func put(tx *bolt.Tx, bucket string, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(bucket)).Put([]byte(key), data)
+}
+
+// forEach decodes every record in bucket with newRecord and passes it to fn
+func forEach(tx *bolt.Tx, bucket string, newRecord func() interface{}, fn func(record interface{}) error) error {
+	return tx.Bucket([]byte(bucket)).ForEach(func(_, data []byte) error {
+		record := newRecord()
+		if err := json.Unmarshal(data, record); err != nil {
+			return err
+		}
+		return fn(record)
+	})
+}
+
+// now returns the current time rounded like rethinkdb's millisecond time precision
+func now() time.Time {
+	return time.Now().UTC().Round(time.Millisecond)
+}
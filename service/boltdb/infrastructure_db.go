@@ -0,0 +1,195 @@
+package boltdb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/concur/eve"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (s *Store) InsertInfrastructure(infra *eve.Infrastructure) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(infra.Name)
+		var existing eve.Infrastructure
+		if found, err := get(tx, INFRA_BUCKET, key, &existing); err != nil {
+			return err
+		} else if found {
+			return fmt.Errorf("Infrastructure %s already exists", infra.Name)
+		}
+		stored := *infra
+		stored.Id = key
+		stored.State = nil
+		stored.CreatedAt = now()
+		stored.UpdatedAt = stored.CreatedAt
+		return put(tx, INFRA_BUCKET, key, &stored)
+	})
+}
+
+func (s *Store) UpdateInfrastructureState(name string, state map[string]interface{}, condition *eve.StateCondition) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(name)
+		var infra eve.Infrastructure
+		found, err := get(tx, INFRA_BUCKET, key, &infra)
+		if err != nil || !found {
+			return err
+		}
+		if err := updateInfrastructureState(tx, &infra, state, condition); err != nil {
+			return err
+		}
+		return put(tx, INFRA_BUCKET, key, &infra)
+	})
+}
+
+// updateInfrastructureState replaces infrastructure's state when condition accepts it. The state's lock is read
+// in tx, so a lock inserted concurrently cannot be bypassed
+func updateInfrastructureState(tx *bolt.Tx, infra *eve.Infrastructure, state map[string]interface{}, condition *eve.StateCondition) error {
+	var lock *eve.StateLock
+	var stored eve.StateLock
+	if found, err := get(tx, STATE_LOCK_BUCKET, nameKey(infra.Name), &stored); err != nil {
+		return err
+	} else if found {
+		lock = &stored
+	}
+	if err := condition.Check(infra.Name, lock, infra.State, state); err != nil {
+		return err
+	}
+	infra.State = state
+	infra.UpdatedAt = now()
+	return nil
+}
+
+// UpdateInfrastructureStatus moves infrastructure to status, rejecting transitions not in eve.InfrastructureTransitions
+func (s *Store) UpdateInfrastructureStatus(name string, status eve.Status) error {
+	return s.transitInfrastructure(name, status, func(infra *eve.Infrastructure) {
+		infra.Status = status
+	})
+}
+
+func (s *Store) UpdateInfrastructureError(name string, infraError error) error {
+	if infraError == nil {
+		return s.updateInfrastructure(name, func(infra *eve.Infrastructure) error {
+			infra.Error = ""
+			infra.UpdatedAt = now()
+			return nil
+		})
+	}
+	return s.transitInfrastructure(name, eve.FAILED, func(infra *eve.Infrastructure) {
+		infra.Status = eve.FAILED
+		infra.Error = infraError.Error()
+	})
+}
+
+// transitInfrastructure checks and applies the transition in one write transaction,
+// so concurrent transitions cannot both succeed
+func (s *Store) transitInfrastructure(name string, to eve.Status, fn func(infra *eve.Infrastructure)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(name)
+		var infra eve.Infrastructure
+		found, err := get(tx, INFRA_BUCKET, key, &infra)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("Infrastructure %s doesn't exist", name)
+		}
+		if !infra.Status.CanTransitionTo(to) {
+			return &eve.TransitionError{Name: name, From: infra.Status, To: to}
+		}
+		fn(&infra)
+		infra.RecordTransition(to, now())
+		return put(tx, INFRA_BUCKET, key, &infra)
+	})
+}
+
+func (s *Store) UpdateInfrastructureAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	return s.updateInfrastructure(name, func(infra *eve.Infrastructure) error {
+		infra.Authorization.GrantAccess(group, mode)
+		return nil
+	})
+}
+
+func (s *Store) DeleteInfrastructureAccess(name string, group eve.Group) error {
+	return s.updateInfrastructure(name, func(infra *eve.Infrastructure) error {
+		delete(infra.Authorization.GroupAccess, group)
+		return nil
+	})
+}
+
+func (s *Store) UpdateInfrastructureOwner(name string, from eve.UserId, to eve.UserId) error {
+	return s.updateInfrastructure(name, func(infra *eve.Infrastructure) error {
+		infra.Authorization.TransferOwnership(from, to)
+		return nil
+	})
+}
+
+func (s *Store) GetInfrastructureByName(name string) (*eve.Infrastructure, error) {
+	var infra eve.Infrastructure
+	var found bool
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx, INFRA_BUCKET, nameKey(name), &infra)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &infra, nil
+}
+
+// GetInfrastructuresByQuoin returns infrastructures using the quoin which aren't destroyed, including failed ones
+func (s *Store) GetInfrastructuresByQuoin(name string) ([]eve.Infrastructure, error) {
+	var infras []eve.Infrastructure
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEach(tx, INFRA_BUCKET, func() interface{} { return &eve.Infrastructure{} }, func(record interface{}) error {
+			infra := record.(*eve.Infrastructure)
+			if infra.Status != eve.DESTROYED && infra.Quoin != nil && infra.Quoin.Name == name {
+				infra.State = nil
+				infras = append(infras, *infra)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infras, func(i, j int) bool { return infras[i].Name < infras[j].Name })
+	return infras, nil
+}
+
+func (s *Store) ListInfrastructures(options eve.ListOptions) ([]eve.Infrastructure, error) {
+	var infras []eve.Infrastructure
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEach(tx, INFRA_BUCKET, func() interface{} { return &eve.Infrastructure{} }, func(record interface{}) error {
+			if infra := record.(*eve.Infrastructure); options.MatchInfrastructure(infra) {
+				infra.State = nil
+				infras = append(infras, *infra)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infras, func(i, j int) bool { return infras[i].Name < infras[j].Name })
+	if options.Limit > 0 && len(infras) > options.Limit {
+		infras = infras[:options.Limit]
+	}
+	return infras, nil
+}
+
+// updateInfrastructure applies fn to the stored infrastructure. Missing infrastructure is skipped like rethinkdb's update.
+func (s *Store) updateInfrastructure(name string, fn func(infra *eve.Infrastructure) error) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(name)
+		var infra eve.Infrastructure
+		found, err := get(tx, INFRA_BUCKET, key, &infra)
+		if err != nil || !found {
+			return err
+		}
+		if err := fn(&infra); err != nil {
+			return err
+		}
+		return put(tx, INFRA_BUCKET, key, &infra)
+	})
+}
@@ -0,0 +1,110 @@
+package boltdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/concur/eve"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (s *Store) InsertProvider(provider *eve.Provider) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(provider.Name)
+		var existing eve.Provider
+		if found, err := get(tx, PROVIDER_BUCKET, key, &existing); err != nil {
+			return err
+		} else if found {
+			return fmt.Errorf("Provider %s already exists", provider.Name)
+		}
+		provider.Id = key
+		provider.CreatedAt = now()
+		provider.UpdatedAt = provider.CreatedAt
+		return put(tx, PROVIDER_BUCKET, key, provider)
+	})
+}
+
+// UpdateProvider replaces provider's schema. Provider's authorization is kept as it is
+func (s *Store) UpdateProvider(name string, provider *eve.Provider) error {
+	return s.updateProvider(name, func(stored *eve.Provider) {
+		stored.Schema = provider.Schema
+	})
+}
+
+func (s *Store) UpdateProviderAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	return s.updateProvider(name, func(provider *eve.Provider) {
+		provider.Authorization.GrantAccess(group, mode)
+	})
+}
+
+func (s *Store) DeleteProviderAccess(name string, group eve.Group) error {
+	return s.updateProvider(name, func(provider *eve.Provider) {
+		delete(provider.Authorization.GroupAccess, group)
+	})
+}
+
+// updateProvider applies fn to the stored provider. Missing provider is skipped like rethinkdb's update.
+func (s *Store) updateProvider(name string, fn func(provider *eve.Provider)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(name)
+		var provider eve.Provider
+		found, err := get(tx, PROVIDER_BUCKET, key, &provider)
+		if err != nil || !found {
+			return err
+		}
+		fn(&provider)
+		provider.UpdatedAt = now()
+		return put(tx, PROVIDER_BUCKET, key, &provider)
+	})
+}
+
+func (s *Store) DeleteProvider(name string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(PROVIDER_BUCKET)).Delete([]byte(nameKey(name)))
+	})
+}
+
+func (s *Store) GetProviderByName(name string) (*eve.Provider, error) {
+	var provider eve.Provider
+	var found bool
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx, PROVIDER_BUCKET, nameKey(name), &provider)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &provider, nil
+}
+
+func (s *Store) GetProviders() ([]eve.Provider, error) {
+	var providers []eve.Provider
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEach(tx, PROVIDER_BUCKET, func() interface{} { return &eve.Provider{} }, func(record interface{}) error {
+			providers = append(providers, *record.(*eve.Provider))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers, nil
+}
+
+// CountInfrastructuresByProvider counts not yet destroyed infrastructures using the provider
+func (s *Store) CountInfrastructuresByProvider(name string) (int, error) {
+	count := 0
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEach(tx, INFRA_BUCKET, func() interface{} { return &eve.Infrastructure{} }, func(record interface{}) error {
+			infra := record.(*eve.Infrastructure)
+			if infra.Status < eve.DESTROYED && strings.HasPrefix(infra.ProviderSlug, name+":") {
+				count++
+			}
+			return nil
+		})
+	})
+	return count, err
+}
@@ -0,0 +1,190 @@
+package boltdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/concur/eve"
+	"github.com/pborman/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+func (s *Store) InsertQuoin(quoin *eve.Quoin) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(quoin.Name)
+		var existing eve.Quoin
+		if found, err := get(tx, QUOIN_BUCKET, key, &existing); err != nil {
+			return err
+		} else if found {
+			return fmt.Errorf("Quoin %s already exists", quoin.Name)
+		}
+		quoin.Id = key
+		quoin.Status = eve.DEFAULT
+		quoin.CreatedAt = now()
+		quoin.UpdatedAt = quoin.CreatedAt
+		return put(tx, QUOIN_BUCKET, key, quoin)
+	})
+}
+
+func (s *Store) UpdateQuoin(name string, quoin *eve.Quoin) error {
+	return s.updateQuoin(name, func(stored *eve.Quoin) {
+		id, createdAt := stored.Id, stored.CreatedAt
+		*stored = *quoin
+		stored.Id = id
+		stored.CreatedAt = createdAt
+		stored.UpdatedAt = now()
+	})
+}
+
+func (s *Store) UpdateQuoinAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	return s.updateQuoin(name, func(quoin *eve.Quoin) {
+		quoin.Authorization.GrantAccess(group, mode)
+	})
+}
+
+func (s *Store) DeleteQuoinAccess(name string, group eve.Group) error {
+	return s.updateQuoin(name, func(quoin *eve.Quoin) {
+		delete(quoin.Authorization.GroupAccess, group)
+	})
+}
+
+// UpdateQuoinOwner transfers ownership of the quoin and all of its archives
+func (s *Store) UpdateQuoinOwner(name string, from eve.UserId, to eve.UserId) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if err := updateQuoinTx(tx, name, func(quoin *eve.Quoin) {
+			quoin.Authorization.TransferOwnership(from, to)
+		}); err != nil {
+			return err
+		}
+		var archives []*eve.QuoinArchive
+		if err := forEach(tx, QUOIN_ARCHIVE_BUCKET, func() interface{} { return &eve.QuoinArchive{} }, func(record interface{}) error {
+			if archive := record.(*eve.QuoinArchive); archive.QuoinName == name {
+				archives = append(archives, archive)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, archive := range archives {
+			archive.Authorization.TransferOwnership(from, to)
+			if err := put(tx, QUOIN_ARCHIVE_BUCKET, archive.Id, archive); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) GetQuoinByName(name string) (*eve.Quoin, error) {
+	var quoin eve.Quoin
+	var found bool
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx, QUOIN_BUCKET, nameKey(name), &quoin)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &quoin, nil
+}
+
+func (s *Store) ListQuoins(options eve.ListOptions) ([]eve.Quoin, error) {
+	var quoins []eve.Quoin
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEach(tx, QUOIN_BUCKET, func() interface{} { return &eve.Quoin{} }, func(record interface{}) error {
+			if quoin := record.(*eve.Quoin); options.MatchQuoin(quoin) {
+				quoins = append(quoins, *quoin)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(quoins, func(i, j int) bool { return quoins[i].Name < quoins[j].Name })
+	if options.Limit > 0 && len(quoins) > options.Limit {
+		quoins = quoins[:options.Limit]
+	}
+	return quoins, nil
+}
+
+func (s *Store) InsertQuoinArchive(archive *eve.QuoinArchive) error {
+	return s.update(func(tx *bolt.Tx) error {
+		id := uuid.NewRandom().String()
+		found := false
+		if err := updateQuoinTx(tx, archive.QuoinName, func(quoin *eve.Quoin) {
+			found = true
+			// Update Quoin with Archive's id value
+			quoin.ArchiveUri = strings.SplitAfter(quoin.ArchiveUri, "/upload")[0] + "/" + id
+			quoin.Status = eve.VALIDATED
+			quoin.UpdatedAt = now()
+		}); err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("Quoin %s doesn't exist", archive.QuoinName)
+		}
+		archive.Id = id
+		archive.CreatedAt = now()
+		return put(tx, QUOIN_ARCHIVE_BUCKET, id, archive)
+	})
+}
+
+func (s *Store) GetQuoinArchiveById(id string) (*eve.QuoinArchive, error) {
+	var archive eve.QuoinArchive
+	var found bool
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx, QUOIN_ARCHIVE_BUCKET, id, &archive)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// GetQuoinArchivesByQuoin returns the quoin's archives without their modules, newest first
+func (s *Store) GetQuoinArchivesByQuoin(quoinName string) ([]eve.QuoinArchive, error) {
+	var archives []eve.QuoinArchive
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEach(tx, QUOIN_ARCHIVE_BUCKET, func() interface{} { return &eve.QuoinArchive{} }, func(record interface{}) error {
+			if archive := record.(*eve.QuoinArchive); archive.QuoinName == quoinName {
+				archive.Modules = nil
+				archives = append(archives, *archive)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].CreatedAt.After(archives[j].CreatedAt) })
+	return archives, nil
+}
+
+func (s *Store) DeleteQuoinArchive(id string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(QUOIN_ARCHIVE_BUCKET)).Delete([]byte(id))
+	})
+}
+
+func (s *Store) updateQuoin(name string, fn func(quoin *eve.Quoin)) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return updateQuoinTx(tx, name, fn)
+	})
+}
+
+// updateQuoinTx applies fn to the stored quoin. Missing quoin is skipped like rethinkdb's update.
+func updateQuoinTx(tx *bolt.Tx, name string, fn func(quoin *eve.Quoin)) error {
+	key := nameKey(name)
+	var quoin eve.Quoin
+	found, err := get(tx, QUOIN_BUCKET, key, &quoin)
+	if err != nil || !found {
+		return err
+	}
+	fn(&quoin)
+	return put(tx, QUOIN_BUCKET, key, &quoin)
+}
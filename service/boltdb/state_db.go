@@ -0,0 +1,136 @@
+package boltdb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/concur/eve"
+	"github.com/pborman/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// InsertStateVersion checks condition, makes version its infrastructure's current state and stores version
+// in one transaction
+func (s *Store) InsertStateVersion(version *eve.StateVersion, condition *eve.StateCondition) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(version.InfrastructureName)
+		var infra eve.Infrastructure
+		found, err := get(tx, INFRA_BUCKET, key, &infra)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("Infrastructure %s doesn't exist", version.InfrastructureName)
+		}
+		if err := updateInfrastructureState(tx, &infra, version.State, condition); err != nil {
+			return err
+		}
+		if err := put(tx, INFRA_BUCKET, key, &infra); err != nil {
+			return err
+		}
+		version.Id = uuid.NewRandom().String()
+		version.CreatedAt = infra.UpdatedAt
+		return put(tx, STATE_VERSION_BUCKET, version.Id, version)
+	})
+}
+
+func (s *Store) GetStateVersionById(id string) (*eve.StateVersion, error) {
+	var version eve.StateVersion
+	var found bool
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx, STATE_VERSION_BUCKET, id, &version)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// GetStateVersionsByInfrastructure returns the infrastructure's versions without their states, newest first
+func (s *Store) GetStateVersionsByInfrastructure(name string) ([]eve.StateVersion, error) {
+	var versions []eve.StateVersion
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEach(tx, STATE_VERSION_BUCKET, func() interface{} { return &eve.StateVersion{} }, func(record interface{}) error {
+			if version := record.(*eve.StateVersion); version.InfrastructureName == name {
+				version.State = nil
+				versions = append(versions, *version)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return newerStateVersion(versions[i], versions[j]) })
+	return versions, nil
+}
+
+// newerStateVersion orders versions by write time, then by serial like rethinkdb's order
+func newerStateVersion(a, b eve.StateVersion) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.Serial > b.Serial
+}
+
+func (s *Store) UpdateStateVersionState(id string, state map[string]interface{}) error {
+	return s.update(func(tx *bolt.Tx) error {
+		var version eve.StateVersion
+		found, err := get(tx, STATE_VERSION_BUCKET, id, &version)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("State version %s not found", id)
+		}
+		version.State = state
+		return put(tx, STATE_VERSION_BUCKET, id, &version)
+	})
+}
+
+// InsertStateLock checks and stores lock in one transaction, so only one lock of a state can be inserted
+func (s *Store) InsertStateLock(lock *eve.StateLock) (*eve.StateLock, error) {
+	var existing eve.StateLock
+	var locked bool
+	err := s.update(func(tx *bolt.Tx) error {
+		key := nameKey(lock.InfrastructureName)
+		var err error
+		if locked, err = get(tx, STATE_LOCK_BUCKET, key, &existing); err != nil || locked {
+			return err
+		}
+		lock.Created = now()
+		return put(tx, STATE_LOCK_BUCKET, key, lock)
+	})
+	if err != nil || !locked {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s *Store) GetStateLock(name string) (*eve.StateLock, error) {
+	var lock eve.StateLock
+	var found bool
+	err := s.view(func(tx *bolt.Tx) error {
+		var err error
+		found, err = get(tx, STATE_LOCK_BUCKET, nameKey(name), &lock)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func (s *Store) DeleteStateLock(name string, id string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		key := nameKey(name)
+		var lock eve.StateLock
+		found, err := get(tx, STATE_LOCK_BUCKET, key, &lock)
+		if err != nil || !found || (id != "" && lock.ID != id) {
+			return err
+		}
+		return tx.Bucket([]byte(STATE_LOCK_BUCKET)).Delete([]byte(key))
+	})
+}
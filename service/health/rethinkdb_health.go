@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"github.com/concur/eve"
 	"github.com/concur/eve/pkg/config"
+	"github.com/concur/eve/service/rethinkdb"
 	r "gopkg.in/gorethink/gorethink.v3"
 	"strconv"
 	"strings"
@@ -142,24 +143,24 @@ func (rChecker *RethinkdbChecker) TableReady() *eve.Error {
 		}
 	}
 
-	tables := map[string]byte{
-		"quoin":          0,
-		"quoinArchive":   0,
-		"infrastructure": 0,
-	}
-
+	// Tables are checked against the same definitions eve db migrate creates them from
+	existing := make(map[string]bool)
 	var row interface{}
 	for cursor.Next(&row) {
-		name := row.(string)
-		if _, ok := tables[name]; ok {
-			delete(tables, name)
+		existing[row.(string)] = true
+	}
+
+	var missing []string
+	for _, table := range rethinkdb.Tables() {
+		if !existing[table] {
+			missing = append(missing, table)
 		}
 	}
 
-	if len(tables) > 0 {
+	if len(missing) > 0 {
 		err := "Table:"
-		for key := range tables {
-			err = strings.Join([]string{err, key, "is not found."}, " ")
+		for _, table := range missing {
+			err = strings.Join([]string{err, table, "is not found."}, " ")
 		}
 		return &eve.Error{
 			Type:        "RethinkDB",
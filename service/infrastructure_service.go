@@ -2,19 +2,21 @@ package service
 
 import (
 	"fmt"
+	"strconv"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
 	"github.com/concur/eve/service/nats"
-	"github.com/concur/eve/service/rethinkdb"
 )
 
 type InfrastructureService struct {
 	*eve.User
+	store eve.Store
 }
 
 const (
 	INVALID_QUOIN_ERROR = "To create an infrastructure, please use a valid quoin"
+	REGION_VARIABLE     = "region"
 )
 
 func NewInfrastructureService(user *eve.User) *InfrastructureService {
@@ -23,9 +25,40 @@ func NewInfrastructureService(user *eve.User) *InfrastructureService {
 	}
 }
 
+// NewInfrastructureServiceWithStore creates InfrastructureService using the given store instead of the default store
+func NewInfrastructureServiceWithStore(user *eve.User, store eve.Store) *InfrastructureService {
+	return &InfrastructureService{
+		User:  user,
+		store: store,
+	}
+}
+
+func (infraSvc InfrastructureService) db() eve.Store {
+	return resolveStore(infraSvc.store)
+}
+
+// GetInfrastructure returns the infrastructure, or nil when it doesn't exist. Its decrypted state is returned
+// only to users authorized to execute the infrastructure, because the state carries secrets
 func (infraSvc InfrastructureService) GetInfrastructure(name string) (*eve.Infrastructure, error) {
+	infrastructure, err := infraSvc.getInfrastructure(name)
+	if err != nil || infrastructure == nil {
+		return nil, err
+	}
+
+	if !infrastructure.AuthorizedExecute(infraSvc.User) {
+		infrastructure.State = nil
+		return infrastructure, nil
+	}
+	if err := decryptInfrastructureState(infrastructure); err != nil {
+		return nil, err
+	}
+	return infrastructure, nil
+}
+
+// getInfrastructure returns the infrastructure readable by the user without decrypting its state
+func (infraSvc InfrastructureService) getInfrastructure(name string) (*eve.Infrastructure, error) {
 	log.Infoln("Get Infrastructure for user:", infraSvc.User)
-	db := rethinkdb.DefaultSession()
+	db := infraSvc.db()
 	infrastructure, err := db.GetInfrastructureByName(name)
 	if err != nil {
 		return nil, err
@@ -36,12 +69,13 @@ func (infraSvc InfrastructureService) GetInfrastructure(name string) (*eve.Infra
 	}
 
 	if !infrastructure.AuthorizedRead(infraSvc.User) {
-		return nil, fmt.Errorf("User %s is not authorized to read infrastructure %s", infraSvc.User.Id, infrastructure.Name)
+		return nil, &eve.ForbiddenError{User: infraSvc.User.Id, Action: "read infrastructure " + infrastructure.Name}
 	}
 
 	return infrastructure, nil
 }
 
+// GetInfrastructuresByQuoin returns infrastructures using the quoin without their states
 func (infraSvc InfrastructureService) GetInfrastructuresByQuoin(quoinName string) ([]eve.Infrastructure, error) {
 	infras, err := infraSvc.getInfrastructuresByQuoin(quoinName)
 	if err != nil {
@@ -53,10 +87,36 @@ func (infraSvc InfrastructureService) GetInfrastructuresByQuoin(quoinName string
 		if !infra.AuthorizedRead(infraSvc.User) {
 			infras[i] = eve.Infrastructure{Name: infra.Name}
 		}
+		infras[i].State = nil
 	}
 	return infras, err
 }
 
+// ListInfrastructures returns a page of infrastructures matching options without their states. If user is not
+// authorized to read an infrastructure, only the infrastructure's name is returned
+func (infraSvc InfrastructureService) ListInfrastructures(options eve.ListOptions) (*eve.InfrastructureList, error) {
+	limit := listLimit(options.Limit)
+	options.Limit = limit + 1
+	infras, err := infraSvc.db().ListInfrastructures(options)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &eve.InfrastructureList{Items: []eve.Infrastructure{}}
+	if len(infras) > limit {
+		infras = infras[:limit]
+		list.Next = EncodeCursor(infras[limit-1].Name)
+	}
+	for _, infra := range infras {
+		if !infra.AuthorizedRead(infraSvc.User) {
+			infra = eve.Infrastructure{Name: infra.Name}
+		}
+		infra.State = nil
+		list.Items = append(list.Items, infra)
+	}
+	return list, nil
+}
+
 func (infraSvc InfrastructureService) CountInfrastructureByQuoin(quoinName string) (int, error) {
 	infras, err := infraSvc.getInfrastructuresByQuoin(quoinName)
 	if err != nil {
@@ -66,7 +126,7 @@ func (infraSvc InfrastructureService) CountInfrastructureByQuoin(quoinName strin
 }
 
 func (infraSvc InfrastructureService) getInfrastructuresByQuoin(quoinName string) ([]eve.Infrastructure, error) {
-	db := rethinkdb.DefaultSession()
+	db := infraSvc.db()
 	infrastructures, err := db.GetInfrastructuresByQuoin(quoinName)
 	if err != nil {
 		return nil, err
@@ -74,8 +134,10 @@ func (infraSvc InfrastructureService) getInfrastructuresByQuoin(quoinName string
 	return infrastructures, nil
 }
 
+// GetInfrastructureState returns infrastructure's decrypted state. Only users authorized to execute
+// the infrastructure can read its state
 func (infraSvc InfrastructureService) GetInfrastructureState(name string) (map[string]interface{}, error) {
-	infra, err := infraSvc.GetInfrastructure(name)
+	infra, err := infraSvc.getInfrastructure(name)
 	if err != nil {
 		return nil, err
 	}
@@ -84,26 +146,86 @@ func (infraSvc InfrastructureService) GetInfrastructureState(name string) (map[s
 		return nil, nil
 	}
 
+	if !infra.AuthorizedExecute(infraSvc.User) {
+		return nil, &eve.ForbiddenError{User: infraSvc.User.Id, Action: "read state of infrastructure " + name}
+	}
+
+	if err := decryptInfrastructureState(infra); err != nil {
+		return nil, err
+	}
 	return infra.State, nil
 }
 
+// GetInfrastructureOutputs returns the outputs of infrastructure's state, or nil when the infrastructure doesn't exist.
+// Values of sensitive outputs are masked unless the user is authorized to execute the infrastructure
+func (infraSvc InfrastructureService) GetInfrastructureOutputs(name string) (map[string]eve.StateOutput, error) {
+	infra, err := infraSvc.getInfrastructure(name)
+	if err != nil || infra == nil {
+		return nil, err
+	}
+
+	if err := decryptInfrastructureState(infra); err != nil {
+		return nil, err
+	}
+
+	outputs := eve.StateOutputs(infra.State)
+	if !infra.AuthorizedExecute(infraSvc.User) {
+		for key, output := range outputs {
+			if output.Sensitive {
+				output.Value = eve.MASKED_OUTPUT
+				outputs[key] = output
+			}
+		}
+	}
+	return outputs, nil
+}
+
 func (infraSvc InfrastructureService) CreateInfrastructure(infra *eve.Infrastructure) error {
-	searchResult, err := infraSvc.GetInfrastructure(infra.Name)
+	searchResult, err := infraSvc.getInfrastructure(infra.Name)
 	if err != nil {
 		return err
 	}
 
+	if searchResult != nil {
+		if !searchResult.AuthorizedWrite(infraSvc.User) {
+			return fmt.Errorf("User %s is not authorized to modify infrastructure %s", infraSvc.User.Id, infra.Name)
+		}
+		// Re-create runs the stored infrastructure, not the request's provider, role, variables or quoin archive
+		*infra = *searchResult
+		// Avoid NATS queue message size limit
+		infra.State = nil
+	}
+
+	// Validate user's permission on infrastructure's provider and account
+	providerSvc := NewProviderServiceWithStore(infraSvc.User, infraSvc.store)
+	account, err := providerSvc.GetAuthorizedAccount(infra.ProviderSlug)
+	if err != nil {
+		return err
+	}
+
+	// Validate infrastructure's region and role against account's allowlists
+	if err := account.CheckUsage(infra.Variable(REGION_VARIABLE), infra.Role); err != nil {
+		return err
+	}
+
 	if searchResult != nil {
 		log.Printf("Found existing infrastructure %s.\n", infra.Name)
-		switch searchResult.Status {
-		case eve.RUNNING, eve.DEPLOYED, eve.OBSOLETED:
-			return fmt.Errorf("Infrastructure %s cannot be created at this moment. Please check its current status first.", infra.Name)
-		default:
+		switch {
+		case searchResult.Status == eve.VALIDATED:
+			// Creation is queued but not started yet, republish it. Agent's RUNNING transition rejects duplicates.
+			log.Printf("Re-publish queued infrastructure %s.\n", infra.Name)
+		case searchResult.Status.CanTransitionTo(eve.VALIDATED):
 			log.Printf("Re-create existing infrastructure %s.\n", infra.Name)
+			db := infraSvc.db()
+			if err := db.UpdateInfrastructureStatus(infra.Name, eve.VALIDATED); err != nil {
+				return err
+			}
+		default:
+			return &eve.TransitionError{Name: infra.Name, From: searchResult.Status, To: eve.VALIDATED}
 		}
 	} else {
 		// Validate infrastructure's quoin reference
-		quoinSvc := NewQuoinService(infraSvc.User)
+		quoinSvc := NewQuoinServiceWithStore(infraSvc.User, infraSvc.store)
 		quoin, err := quoinSvc.GetQuoin(infra.Quoin.Name)
 		if err != nil {
 			return err
@@ -128,8 +250,9 @@ func (infraSvc InfrastructureService) CreateInfrastructure(infra *eve.Infrastruc
 		}
 
 		infra.Status = eve.VALIDATED
+		infra.Organization = infraSvc.User.Organization
 
-		db := rethinkdb.DefaultSession()
+		db := infraSvc.db()
 		if err := db.InsertInfrastructure(infra); err != nil {
 			return err
 		}
@@ -148,18 +271,23 @@ func (infraSvc InfrastructureService) DeleteInfrastructure(name string) error {
 		return err
 	}
 
-	db := rethinkdb.DefaultSession()
+	db := infraSvc.db()
 	infra, err := db.GetInfrastructureByName(name)
 	if err != nil {
 		return err
 	}
 
+	if infra == nil {
+		return fmt.Errorf("Infrastructure %s doesn't exist", name)
+	}
+
 	if len(infra.State) == 0 {
 		return fmt.Errorf("Infrastructure %s's state is missing", name)
 	}
 
-	if infra.Status == eve.RUNNING {
-		return fmt.Errorf("Infrastructure %s's deletion is in process", name)
+	// Only deployed or failed infrastructure can be destroyed, a queued creation moves to RUNNING as well
+	if infra.Status == eve.VALIDATED || !infra.Status.CanTransitionTo(eve.RUNNING) {
+		return &eve.TransitionError{Name: name, From: infra.Status, To: eve.RUNNING}
 	}
 
 	// Avoid NATS queue message size limit
@@ -172,29 +300,370 @@ func (infraSvc InfrastructureService) DeleteInfrastructure(name string) error {
 	return nil
 }
 
+// DeleteInfrastructureState clears infrastructure's current state. Its state versions are kept.
+// Only eve admin users can delete state
 func (infraSvc InfrastructureService) DeleteInfrastructureState(name string) error {
+	if !infraSvc.User.IsAdmin() {
+		return &eve.ForbiddenError{User: infraSvc.User.Id, Action: "delete state of infrastructure " + name}
+	}
+
+	db := infraSvc.db()
+	infra, err := db.GetInfrastructureByName(name)
+	if err != nil {
+		return err
+	}
+
+	if infra == nil {
+		return fmt.Errorf("Infrastructure %s not found", name)
+	}
+
+	// The store checks the lock in the same write as the state, so a lock taken concurrently isn't bypassed
+	if err := db.UpdateInfrastructureState(name, nil, &eve.StateCondition{}); err != nil {
+		return err
+	}
+
+	serial, lineage := eve.StateSerial(infra.State)
+	return auditStateChange(db, eve.AUDIT_DELETE_STATE, name, infraSvc.User.Id, map[string]string{
+		"serial":  strconv.FormatInt(serial, 10),
+		"lineage": lineage,
+	})
+}
+
+// UpdateInfrastructureState stores state as a new version and makes it infrastructure's current state.
+// While the state is locked, lockId must be the lock's id. It returns *eve.StaleStateError when state has
+// a lower serial or another lineage than the current state, unless an eve admin user forces the write
+func (infraSvc InfrastructureService) UpdateInfrastructureState(name string, state map[string]interface{}, lockId string, force bool) error {
+	if err := infraSvc.checkWritePermission(name); err != nil {
+		return err
+	}
+
+	if force && !infraSvc.User.IsAdmin() {
+		return &eve.ForbiddenError{User: infraSvc.User.Id, Action: "force state of infrastructure " + name}
+	}
+
+	db := infraSvc.db()
+	infra, err := db.GetInfrastructureByName(name)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := DefaultStateCipher().Encrypt(infra.Organization, state)
+	if err != nil {
+		return err
+	}
+
+	// The store checks the lock, serial and lineage in the same write as the state, so concurrent pushes
+	// cannot both pass and a lock taken concurrently isn't bypassed
+	version := eve.NewStateVersion(name, encrypted, infraSvc.User.Id)
+	err = db.InsertStateVersion(version, &eve.StateCondition{LockId: lockId, RejectStale: true})
+	staleErr, stale := err.(*eve.StaleStateError)
+	if !stale {
+		return err
+	}
+
+	if !force {
+		log.Printf("State write of user %s (%s) to infrastructure %s is rejected: %v", infraSvc.User.Id, infraSvc.User.Organization, name, staleErr)
+		return staleErr
+	}
+	log.Printf("User %s forces state of infrastructure %s: %v", infraSvc.User.Id, name, staleErr)
+	if err := auditStateChange(db, eve.AUDIT_FORCE_PUSH_STATE, name, infraSvc.User.Id, map[string]string{
+		"reason": staleErr.Error(),
+	}); err != nil {
+		return err
+	}
+	return db.InsertStateVersion(version, &eve.StateCondition{LockId: lockId})
+}
+
+// GetInfrastructureStateVersions returns infrastructure's state versions without their states, newest first.
+// It returns nil when the infrastructure doesn't exist
+func (infraSvc InfrastructureService) GetInfrastructureStateVersions(name string) ([]eve.StateVersion, error) {
+	infra, err := infraSvc.getInfrastructure(name)
+	if err != nil || infra == nil {
+		return nil, err
+	}
+
+	db := infraSvc.db()
+	versions, err := db.GetStateVersionsByInfrastructure(name)
+	if err != nil {
+		return nil, err
+	}
+	if versions == nil {
+		versions = []eve.StateVersion{}
+	}
+	return versions, nil
+}
+
+// GetInfrastructureStateVersion returns the infrastructure's state version, or nil when it doesn't exist.
+// Like the current state, versions are only readable by users authorized to execute the infrastructure
+func (infraSvc InfrastructureService) GetInfrastructureStateVersion(name string, id string) (*eve.StateVersion, error) {
+	infra, err := infraSvc.getInfrastructure(name)
+	if err != nil || infra == nil {
+		return nil, err
+	}
+
+	if !infra.AuthorizedExecute(infraSvc.User) {
+		return nil, &eve.ForbiddenError{User: infraSvc.User.Id, Action: "read state of infrastructure " + name}
+	}
+
+	db := infraSvc.db()
+	version, err := db.GetStateVersionById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == nil || version.InfrastructureName != name {
+		return nil, nil
+	}
+
+	state, err := DefaultStateCipher().Decrypt(version.State)
+	if err != nil {
+		return nil, err
+	}
+	version.State = state
+	return version, nil
+}
+
+// RollbackInfrastructureState restores the state of version id as a new version. The restored state's serial
+// is moved past every earlier version, so terraform treats it as the latest state
+func (infraSvc InfrastructureService) RollbackInfrastructureState(name string, id string) (*eve.StateVersion, error) {
+	if err := infraSvc.checkWritePermission(name); err != nil {
+		return nil, err
+	}
+
+	db := infraSvc.db()
+	version, err := db.GetStateVersionById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == nil || version.InfrastructureName != name {
+		return nil, fmt.Errorf("State version %s of infrastructure %s not found", id, name)
+	}
+
+	versions, err := db.GetStateVersionsByInfrastructure(name)
+	if err != nil {
+		return nil, err
+	}
+	var serial int64
+	for _, v := range versions {
+		if v.Serial > serial {
+			serial = v.Serial
+		}
+	}
+
+	infra, err := db.GetInfrastructureByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher := DefaultStateCipher()
+	state, err := cipher.Decrypt(version.State)
+	if err != nil {
+		return nil, err
+	}
+	restored := make(map[string]interface{}, len(state))
+	for key, value := range state {
+		restored[key] = value
+	}
+	restored["serial"] = serial + 1
+
+	encrypted, err := cipher.Encrypt(infra.Organization, restored)
+	if err != nil {
+		return nil, err
+	}
+
+	rollback := eve.NewStateVersion(name, encrypted, infraSvc.User.Id)
+	rollback.RollbackOf = id
+	// An empty condition only rejects the rollback while the state is locked
+	if err := db.InsertStateVersion(rollback, &eve.StateCondition{}); err != nil {
+		return nil, err
+	}
+
+	if err := auditStateChange(db, eve.AUDIT_ROLLBACK_STATE, name, infraSvc.User.Id, map[string]string{
+		"version": id,
+		"serial":  strconv.FormatInt(rollback.Serial, 10),
+	}); err != nil {
+		return nil, err
+	}
+	return rollback, nil
+}
+
+// RekeyInfrastructureStates encrypts every current state and state version with its infrastructure's
+// organization key, rotating the keys first when rotate is true. It returns the number of rekeyed states.
+// Only eve admin users can rekey states
+func (infraSvc InfrastructureService) RekeyInfrastructureStates(rotate bool) (int, error) {
+	if !infraSvc.User.IsAdmin() {
+		return 0, fmt.Errorf("User %s is not authorized to rekey infrastructure states", infraSvc.User.Id)
+	}
+
+	db := infraSvc.db()
+	var infras []eve.Infrastructure
+	options := eve.ListOptions{Limit: MAX_LIST_LIMIT}
+	for {
+		page, err := db.ListInfrastructures(options)
+		if err != nil {
+			return 0, err
+		}
+		infras = append(infras, page...)
+		if len(page) < options.Limit {
+			break
+		}
+		options.After = page[len(page)-1].Name
+	}
+
+	cipher := DefaultStateCipher()
+	if rotate {
+		rotated := map[eve.Organization]bool{}
+		for _, infra := range infras {
+			if rotated[infra.Organization] {
+				continue
+			}
+			if err := cipher.Rotate(infra.Organization); err != nil {
+				return 0, err
+			}
+			rotated[infra.Organization] = true
+			log.Printf("State key of organization %q is rotated.", infra.Organization)
+		}
+	}
+
+	rekeyed := 0
+	for _, listed := range infras {
+		// Lists don't carry states
+		infra, err := db.GetInfrastructureByName(listed.Name)
+		if err != nil {
+			return rekeyed, err
+		}
+		if infra == nil {
+			continue
+		}
+		if len(infra.State) > 0 {
+			state, err := cipher.Rewrap(infra.Organization, infra.State)
+			if err != nil {
+				return rekeyed, err
+			}
+			if err := db.UpdateInfrastructureState(infra.Name, state, nil); err != nil {
+				return rekeyed, err
+			}
+			rekeyed++
+		}
+
+		versions, err := db.GetStateVersionsByInfrastructure(infra.Name)
+		if err != nil {
+			return rekeyed, err
+		}
+		for _, v := range versions {
+			version, err := db.GetStateVersionById(v.Id)
+			if err != nil {
+				return rekeyed, err
+			}
+			if version == nil || len(version.State) == 0 {
+				continue
+			}
+			state, err := cipher.Rewrap(infra.Organization, version.State)
+			if err != nil {
+				return rekeyed, err
+			}
+			if err := db.UpdateStateVersionState(version.Id, state); err != nil {
+				return rekeyed, err
+			}
+			rekeyed++
+		}
+		log.Printf("States of infrastructure %s are rekeyed.", infra.Name)
+	}
+	return rekeyed, nil
+}
+
+// GetInfrastructureStateLock returns terraform's lock of infrastructure's state, or nil when it isn't locked
+func (infraSvc InfrastructureService) GetInfrastructureStateLock(name string) (*eve.StateLock, error) {
+	infra, err := infraSvc.getInfrastructure(name)
+	if err != nil || infra == nil {
+		return nil, err
+	}
+
+	db := infraSvc.db()
+	return db.GetStateLock(name)
+}
+
+// LockInfrastructureState locks infrastructure's state for terraform. It returns *eve.StateLockError
+// when the state is already locked
+func (infraSvc InfrastructureService) LockInfrastructureState(name string, lock *eve.StateLock) error {
+	if err := infraSvc.checkWritePermission(name); err != nil {
+		return err
+	}
+
+	if lock.ID == "" {
+		return fmt.Errorf("Lock id of infrastructure %s's state is missing", name)
+	}
+
+	lock.InfrastructureName = name
+	lock.Holder = infraSvc.User.Id
+	db := infraSvc.db()
+	existing, err := db.InsertStateLock(lock)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return &eve.StateLockError{Lock: existing}
+	}
+
+	log.Printf("User %s locks state of infrastructure %s with lock %s for %s", infraSvc.User.Id, name, lock.ID, lock.Operation)
 	return nil
 }
 
-func (infraSvc InfrastructureService) UpdateInfrastructureState(name string, state map[string]interface{}) error {
+// UnlockInfrastructureState releases terraform's lock id of infrastructure's state. It returns
+// *eve.StateLockError when the state is locked by another lock
+func (infraSvc InfrastructureService) UnlockInfrastructureState(name string, id string) error {
 	if err := infraSvc.checkWritePermission(name); err != nil {
 		return err
 	}
 
-	db := rethinkdb.DefaultSession()
-	if err := db.UpdateInfrastructureState(name, state); err != nil {
+	if err := infraSvc.checkStateLock(name, id); err != nil {
 		return err
 	}
 
+	db := infraSvc.db()
+	if err := db.DeleteStateLock(name, id); err != nil {
+		return err
+	}
+
+	log.Printf("User %s unlocks state of infrastructure %s with lock %s", infraSvc.User.Id, name, id)
 	return nil
 }
 
+// ForceUnlockInfrastructureState releases any lock of infrastructure's state. Only eve admin users can force unlock
+func (infraSvc InfrastructureService) ForceUnlockInfrastructureState(name string) error {
+	if !infraSvc.User.IsAdmin() {
+		return &eve.ForbiddenError{User: infraSvc.User.Id, Action: "force unlock state of infrastructure " + name}
+	}
+
+	db := infraSvc.db()
+	lock, err := db.GetStateLock(name)
+	if err != nil || lock == nil {
+		return err
+	}
+
+	if err := db.DeleteStateLock(name, lock.ID); err != nil {
+		return err
+	}
+
+	return auditStateChange(db, eve.AUDIT_FORCE_UNLOCK_STATE, name, infraSvc.User.Id, map[string]string{
+		"id":     lock.ID,
+		"holder": string(lock.Holder),
+	})
+}
+
+// UpdateInfrastructureStatus moves infrastructure to status following eve.InfrastructureTransitions
 func (infraSvc InfrastructureService) UpdateInfrastructureStatus(name string, status eve.Status) error {
 	if err := infraSvc.checkWritePermission(name); err != nil {
 		return err
 	}
 
-	db := rethinkdb.DefaultSession()
+	if len(eve.TransitionSources(status)) == 0 {
+		return fmt.Errorf("Infrastructure %s cannot move to status %s", name, status)
+	}
+
+	db := infraSvc.db()
 	if err := db.UpdateInfrastructureStatus(name, status); err != nil {
 		return err
 	}
@@ -207,7 +676,7 @@ func (infraSvc InfrastructureService) UpdateInfrastructureError(name string, inf
 		return err
 	}
 
-	db := rethinkdb.DefaultSession()
+	db := infraSvc.db()
 	if err := db.UpdateInfrastructureError(name, infraError); err != nil {
 		return err
 	}
@@ -215,6 +684,64 @@ func (infraSvc InfrastructureService) UpdateInfrastructureError(name string, inf
 	return nil
 }
 
+// UpdateInfrastructureAccess grants the group given policy mode on the infrastructure
+func (infraSvc InfrastructureService) UpdateInfrastructureAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	if err := infraSvc.checkSharePermission(name); err != nil {
+		return err
+	}
+
+	db := infraSvc.db()
+	if err := db.UpdateInfrastructureAccess(name, group, mode); err != nil {
+		return err
+	}
+
+	log.Printf("User %s grants %s access %s on infrastructure %s", infraSvc.User.Id, group, mode, name)
+	return nil
+}
+
+// DeleteInfrastructureAccess revokes the group's access on the infrastructure
+func (infraSvc InfrastructureService) DeleteInfrastructureAccess(name string, group eve.Group) error {
+	if err := infraSvc.checkSharePermission(name); err != nil {
+		return err
+	}
+
+	db := infraSvc.db()
+	if err := db.DeleteInfrastructureAccess(name, group); err != nil {
+		return err
+	}
+
+	log.Printf("User %s revokes %s access on infrastructure %s", infraSvc.User.Id, group, name)
+	return nil
+}
+
+// TransferInfrastructureOwnership changes the infrastructure's owner. Only eve admin users can transfer ownership
+func (infraSvc InfrastructureService) TransferInfrastructureOwnership(name string, owner eve.UserId) error {
+	if owner == "" {
+		return fmt.Errorf("New owner of infrastructure %s is missing", name)
+	}
+
+	db := infraSvc.db()
+	infra, err := db.GetInfrastructureByName(name)
+	if err != nil {
+		return err
+	}
+
+	if infra == nil {
+		return fmt.Errorf("Infrastructure %s not found", name)
+	}
+
+	if !infra.Authorization.AuthorizedTransfer(infraSvc.User) {
+		return &eve.ForbiddenError{User: infraSvc.User.Id, Action: "transfer ownership of infrastructure " + name}
+	}
+
+	previous := infra.Authorization.Owner
+	if err := db.UpdateInfrastructureOwner(name, previous, owner); err != nil {
+		return err
+	}
+
+	return auditOwnershipTransfer(db, "infrastructure", name, infraSvc.User.Id, previous, owner)
+}
+
 func (infraSvc InfrastructureService) SubscribeAsyncProc(subject eve.Subject, handler eve.InfrastructureAsyncHandler) error {
 	c, err := nats.EncodedConn()
 	if err != nil {
@@ -249,7 +776,7 @@ func (infraSvc InfrastructureService) PublishMessageToQueue(subject eve.Subject,
 }
 
 func (infraSvc InfrastructureService) checkWritePermission(name string) error {
-	db := rethinkdb.DefaultSession()
+	db := infraSvc.db()
 	infra, err := db.GetInfrastructureByName(name)
 	if err != nil {
 		return err
@@ -260,7 +787,48 @@ func (infraSvc InfrastructureService) checkWritePermission(name string) error {
 	}
 
 	if !infra.AuthorizedWrite(infraSvc.User) {
-		return fmt.Errorf("User %s is not authorized to modify infrastructure %s", infraSvc.User.Id, infra.Name)
+		return &eve.ForbiddenError{User: infraSvc.User.Id, Action: "modify infrastructure " + infra.Name}
+	}
+	return nil
+}
+
+// decryptInfrastructureState replaces infrastructure's stored state with its plaintext
+func decryptInfrastructureState(infra *eve.Infrastructure) error {
+	state, err := DefaultStateCipher().Decrypt(infra.State)
+	if err != nil {
+		return err
+	}
+	infra.State = state
+	return nil
+}
+
+// checkStateLock returns *eve.StateLockError when infrastructure's state is locked by a lock other than lockId
+func (infraSvc InfrastructureService) checkStateLock(name string, lockId string) error {
+	db := infraSvc.db()
+	lock, err := db.GetStateLock(name)
+	if err != nil {
+		return err
+	}
+
+	if lock != nil && lock.ID != lockId {
+		return &eve.StateLockError{Lock: lock}
+	}
+	return nil
+}
+
+func (infraSvc InfrastructureService) checkSharePermission(name string) error {
+	db := infraSvc.db()
+	infra, err := db.GetInfrastructureByName(name)
+	if err != nil {
+		return err
+	}
+
+	if infra == nil {
+		return fmt.Errorf("Infrastructure %s not found", name)
+	}
+
+	if !infra.Authorization.AuthorizedShare(infraSvc.User) {
+		return &eve.ForbiddenError{User: infraSvc.User.Id, Action: "change access of infrastructure " + infra.Name}
 	}
 	return nil
 }
@@ -0,0 +1,408 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/service"
+	"github.com/concur/eve/service/memory"
+)
+
+func newInfrastructure(name string, status eve.Status) *eve.Infrastructure {
+	return &eve.Infrastructure{
+		Name:         name,
+		Quoin:        &eve.Quoin{Name: "k8s"},
+		ProviderSlug: "aws:dev",
+		Status:       status,
+		Authorization: eve.Authorization{
+			Owner: ownerUser.Id,
+			GroupAccess: map[eve.Group]eve.PolicyMode{
+				eve.Group(ownerUser.Id): eve.POLICY_ALL,
+				eve.Group("concur"):     eve.POLICY_READ,
+			},
+		},
+	}
+}
+
+func TestInfrastructureService_UpdateInfrastructureStatus(t *testing.T) {
+	store := memory.NewStore()
+	if err := store.InsertInfrastructure(newInfrastructure("dev", eve.VALIDATED)); err != nil {
+		t.Fatal(err)
+	}
+	infraSvc := service.NewInfrastructureServiceWithStore(ownerUser, store)
+
+	if err := infraSvc.UpdateInfrastructureStatus("dev", eve.RUNNING); err != nil {
+		t.Fatalf("VALIDATED to RUNNING should be allowed: %v", err)
+	}
+	err := infraSvc.UpdateInfrastructureStatus("dev", eve.RUNNING)
+	if _, ok := err.(*eve.TransitionError); !ok {
+		t.Errorf("Concurrent RUNNING transition should be rejected, got %v", err)
+	}
+	if err := infraSvc.UpdateInfrastructureError("dev", errors.New("apply failed")); err != nil {
+		t.Fatalf("RUNNING to FAILED should be allowed: %v", err)
+	}
+
+	infra, err := infraSvc.GetInfrastructure("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if infra.Status != eve.FAILED || infra.Error != "apply failed" {
+		t.Errorf("Unexpected infrastructure status %s and error %q", infra.Status, infra.Error)
+	}
+
+	member := &eve.User{Id: "bob", Organization: "concur"}
+	if err := service.NewInfrastructureServiceWithStore(member, store).UpdateInfrastructureStatus("dev", eve.VALIDATED); err == nil {
+		t.Errorf("User without write permission should not update status")
+	}
+}
+
+func TestInfrastructureService_GetInfrastructuresByQuoin(t *testing.T) {
+	store := memory.NewStore()
+	store.InsertInfrastructure(newInfrastructure("dev", eve.DEPLOYED))
+	store.InsertInfrastructure(newInfrastructure("old", eve.DESTROYED))
+	hidden := newInfrastructure("secret", eve.DEPLOYED)
+	hidden.Authorization.GroupAccess = map[eve.Group]eve.PolicyMode{}
+	store.InsertInfrastructure(hidden)
+	store.InsertStateVersion(eve.NewStateVersion("a", map[string]interface{}{"serial": 1.0}, ownerUser.Id), nil)
+
+	member := &eve.User{Id: "bob", Organization: "concur"}
+	infras, err := service.NewInfrastructureServiceWithStore(member, store).GetInfrastructuresByQuoin("k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infras) != 2 {
+		t.Fatalf("Destroyed infrastructure should be excluded, got %v", infras)
+	}
+	if infras[1].Name != "secret" || infras[1].ProviderSlug != "" {
+		t.Errorf("Unreadable infrastructure should only expose its name, got %#v", infras[1])
+	}
+}
+
+func TestInfrastructureService_ListInfrastructures(t *testing.T) {
+	store := memory.NewStore()
+	for _, name := range []string{"a", "b", "c"} {
+		store.InsertInfrastructure(newInfrastructure(name, eve.DEPLOYED))
+	}
+	store.InsertInfrastructure(newInfrastructure("d", eve.FAILED))
+	hidden := newInfrastructure("e", eve.DEPLOYED)
+	hidden.Authorization.GroupAccess = map[eve.Group]eve.PolicyMode{}
+	store.InsertInfrastructure(hidden)
+
+	member := &eve.User{Id: "bob", Organization: "concur"}
+	infraSvc := service.NewInfrastructureServiceWithStore(member, store)
+	deployed := eve.DEPLOYED
+	options := eve.ListOptions{Status: &deployed, Limit: 2}
+
+	page, err := infraSvc.ListInfrastructures(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Name != "a" || page.Items[1].Name != "b" || page.Next == "" {
+		t.Fatalf("First page should list a and b with a cursor, got %#v", page)
+	}
+	if page.Items[0].State != nil {
+		t.Errorf("Listed infrastructure should not carry its state, got %v", page.Items[0].State)
+	}
+
+	options.After, err = service.DecodeCursor(page.Next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err = infraSvc.ListInfrastructures(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Name != "c" || page.Next != "" {
+		t.Fatalf("Last page should list c and e without a cursor, got %#v", page)
+	}
+	if page.Items[1].Name != "e" || page.Items[1].ProviderSlug != "" {
+		t.Errorf("Unreadable infrastructure should only expose its name, got %#v", page.Items[1])
+	}
+}
+
+func TestInfrastructureService_StateVersions(t *testing.T) {
+	store := memory.NewStore()
+	store.InsertInfrastructure(newInfrastructure("dev", eve.DEPLOYED))
+	infraSvc := service.NewInfrastructureServiceWithStore(ownerUser, store)
+
+	for serial := 1; serial <= 3; serial++ {
+		state := map[string]interface{}{"serial": float64(serial), "lineage": "abc"}
+		if err := infraSvc.UpdateInfrastructureState("dev", state, "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err := infraSvc.GetInfrastructureStateVersions("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0].Serial != 3 || versions[2].Serial != 1 {
+		t.Fatalf("Versions should be listed newest first, got %#v", versions)
+	}
+	if versions[0].Writer != ownerUser.Id || versions[0].Lineage != "abc" || versions[0].State != nil {
+		t.Errorf("Unexpected version summary %#v", versions[0])
+	}
+
+	rollback, err := infraSvc.RollbackInfrastructureState("dev", versions[2].Id)
+	if err != nil {
+		t.Fatalf("RollbackInfrastructureState returns error: %v", err)
+	}
+	if rollback.Serial != 4 || rollback.RollbackOf != versions[2].Id {
+		t.Errorf("Rollback should restore version 1 with serial 4, got %#v", rollback)
+	}
+	state, _ := infraSvc.GetInfrastructureState("dev")
+	if serial, _ := eve.StateSerial(state); serial != 4 {
+		t.Errorf("Rolled back state should be current, got %v", state)
+	}
+	if version, _ := infraSvc.GetInfrastructureStateVersion("dev", versions[1].Id); version == nil || version.State["serial"] != float64(2) {
+		t.Errorf("Earlier version should keep its state, got %#v", version)
+	}
+
+	if err := infraSvc.DeleteInfrastructureState("dev"); err == nil {
+		t.Errorf("Only admin users should delete state")
+	}
+	if err := service.NewInfrastructureServiceWithStore(adminUser, store).DeleteInfrastructureState("dev"); err != nil {
+		t.Fatalf("DeleteInfrastructureState returns error: %v", err)
+	}
+	if state, _ := infraSvc.GetInfrastructureState("dev"); len(state) != 0 {
+		t.Errorf("Deleted state should be cleared, got %v", state)
+	}
+	if versions, _ := infraSvc.GetInfrastructureStateVersions("dev"); len(versions) != 4 {
+		t.Errorf("Deleting state should keep its versions, got %d", len(versions))
+	}
+}
+
+func TestInfrastructureService_StateLock(t *testing.T) {
+	store := memory.NewStore()
+	store.InsertInfrastructure(newInfrastructure("dev", eve.DEPLOYED))
+	infraSvc := service.NewInfrastructureServiceWithStore(ownerUser, store)
+	state := map[string]interface{}{"serial": float64(1), "lineage": "abc"}
+
+	if err := infraSvc.LockInfrastructureState("dev", &eve.StateLock{ID: "lock-1", Operation: "OperationTypeApply"}); err != nil {
+		t.Fatalf("LockInfrastructureState returns error: %v", err)
+	}
+	err := infraSvc.LockInfrastructureState("dev", &eve.StateLock{ID: "lock-2"})
+	if lockErr, ok := err.(*eve.StateLockError); !ok || lockErr.Lock.ID != "lock-1" || lockErr.Lock.Holder != ownerUser.Id {
+		t.Fatalf("Locked state should report its lock, got %v", err)
+	}
+	if _, ok := infraSvc.UpdateInfrastructureState("dev", state, "", false).(*eve.StateLockError); !ok {
+		t.Errorf("Locked state should not be written without lock id")
+	}
+	if err := infraSvc.UpdateInfrastructureState("dev", state, "lock-1", false); err != nil {
+		t.Errorf("Lock holder should write state: %v", err)
+	}
+	if _, ok := service.NewInfrastructureServiceWithStore(adminUser, store).DeleteInfrastructureState("dev").(*eve.StateLockError); !ok {
+		t.Errorf("Locked state should not be deleted")
+	}
+	if _, ok := infraSvc.UnlockInfrastructureState("dev", "lock-2").(*eve.StateLockError); !ok {
+		t.Errorf("State should not be unlocked with another lock id")
+	}
+	if err := infraSvc.UnlockInfrastructureState("dev", "lock-1"); err != nil {
+		t.Fatalf("UnlockInfrastructureState returns error: %v", err)
+	}
+
+	if err := infraSvc.LockInfrastructureState("dev", &eve.StateLock{ID: "lock-3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := infraSvc.ForceUnlockInfrastructureState("dev"); err == nil {
+		t.Errorf("Only admin users should force unlock")
+	}
+	if err := service.NewInfrastructureServiceWithStore(adminUser, store).ForceUnlockInfrastructureState("dev"); err != nil {
+		t.Fatalf("ForceUnlockInfrastructureState returns error: %v", err)
+	}
+	if lock, _ := infraSvc.GetInfrastructureStateLock("dev"); lock != nil {
+		t.Errorf("Force unlocked state should not be locked, got %#v", lock)
+	}
+}
+
+func TestInfrastructureService_StaleStateWrite(t *testing.T) {
+	store := memory.NewStore()
+	store.InsertInfrastructure(newInfrastructure("dev", eve.DEPLOYED))
+	infraSvc := service.NewInfrastructureServiceWithStore(ownerUser, store)
+	write := func(svc *service.InfrastructureService, serial float64, lineage string, force bool) error {
+		return svc.UpdateInfrastructureState("dev", map[string]interface{}{"serial": serial, "lineage": lineage}, "", force)
+	}
+
+	if err := write(infraSvc, 5, "abc", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(infraSvc, 5, "abc", false); err != nil {
+		t.Errorf("Same serial should be written: %v", err)
+	}
+	if _, ok := write(infraSvc, 4, "abc", false).(*eve.StaleStateError); !ok {
+		t.Errorf("Lower serial should be rejected")
+	}
+	if _, ok := write(infraSvc, 6, "xyz", false).(*eve.StaleStateError); !ok {
+		t.Errorf("Different lineage should be rejected")
+	}
+	if _, ok := write(infraSvc, 1, "xyz", true).(*eve.ForbiddenError); !ok {
+		t.Errorf("Only admin users should force state")
+	}
+	if err := write(service.NewInfrastructureServiceWithStore(adminUser, store), 1, "xyz", true); err != nil {
+		t.Fatalf("Admin should force state: %v", err)
+	}
+	state, _ := infraSvc.GetInfrastructureState("dev")
+	if serial, lineage := eve.StateSerial(state); serial != 1 || lineage != "xyz" {
+		t.Errorf("Forced state should be current, got %v", state)
+	}
+	if records := store.AuditRecords(); len(records) != 1 || records[0].Action != eve.AUDIT_FORCE_PUSH_STATE {
+		t.Errorf("Forced state should be audited, got %v", records)
+	}
+	if versions, _ := infraSvc.GetInfrastructureStateVersions("dev"); len(versions) != 3 {
+		t.Errorf("Rejected states should not be stored as versions, got %d versions", len(versions))
+	}
+}
+
+// fakeStateCipher keeps state's JSON as its ciphertext, prefixed by the key version
+type fakeStateCipher struct {
+	versions map[eve.Organization]int
+}
+
+func (c *fakeStateCipher) Encrypt(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error) {
+	plaintext, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"serial":             state["serial"],
+		"lineage":            state["lineage"],
+		eve.STATE_KEY:        string(org),
+		eve.STATE_CIPHERTEXT: fmt.Sprintf("v%d:%s", c.versions[org], plaintext),
+	}, nil
+}
+
+func (c *fakeStateCipher) Decrypt(state map[string]interface{}) (map[string]interface{}, error) {
+	if !eve.IsEncryptedState(state) {
+		return state, nil
+	}
+	ciphertext := state[eve.STATE_CIPHERTEXT].(string)
+	var decrypted map[string]interface{}
+	err := json.Unmarshal([]byte(ciphertext[strings.Index(ciphertext, ":")+1:]), &decrypted)
+	return decrypted, err
+}
+
+func (c *fakeStateCipher) Rewrap(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error) {
+	decrypted, err := c.Decrypt(state)
+	if err != nil {
+		return nil, err
+	}
+	return c.Encrypt(org, decrypted)
+}
+
+func (c *fakeStateCipher) Rotate(org eve.Organization) error {
+	c.versions[org]++
+	return nil
+}
+
+func TestInfrastructureService_EncryptedState(t *testing.T) {
+	cipher := &fakeStateCipher{versions: map[eve.Organization]int{}}
+	service.SetStateCipher(cipher)
+	defer service.SetStateCipher(nil)
+
+	store := memory.NewStore()
+	infra := newInfrastructure("dev", eve.DEPLOYED)
+	infra.Organization = "concur"
+	store.InsertInfrastructure(infra)
+	// State written before encryption was enabled
+	store.InsertStateVersion(eve.NewStateVersion("dev", map[string]interface{}{"serial": 1.0, "lineage": "abc", "secret": "old"}, ownerUser.Id), nil)
+	infraSvc := service.NewInfrastructureServiceWithStore(ownerUser, store)
+
+	if err := infraSvc.UpdateInfrastructureState("dev", map[string]interface{}{"serial": 2.0, "lineage": "abc", "secret": "new"}, "", false); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := store.GetInfrastructureByName("dev")
+	if _, ok := stored.State["secret"]; ok || stored.State[eve.STATE_KEY] != "concur" {
+		t.Errorf("State should be stored encrypted with organization's key, got %v", stored.State)
+	}
+	state, err := infraSvc.GetInfrastructureState("dev")
+	if err != nil || state["secret"] != "new" {
+		t.Errorf("State should be decrypted, got %v, %v", state, err)
+	}
+	if _, ok := infraSvc.UpdateInfrastructureState("dev", map[string]interface{}{"serial": 1.0, "lineage": "abc"}, "", false).(*eve.StaleStateError); !ok {
+		t.Errorf("Stale write should be rejected against encrypted state")
+	}
+
+	versions, _ := infraSvc.GetInfrastructureStateVersions("dev")
+	plaintext := versions[len(versions)-1]
+	rollback, err := infraSvc.RollbackInfrastructureState("dev", plaintext.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, _ := infraSvc.GetInfrastructureStateVersion("dev", rollback.Id)
+	if version.State["secret"] != "old" || !eve.IsEncryptedState(rollback.State) {
+		t.Errorf("Rollback should restore plaintext state encrypted, got %v", version.State)
+	}
+
+	if _, err := infraSvc.RekeyInfrastructureStates(true); err == nil {
+		t.Errorf("Only admin users should rekey states")
+	}
+	rekeyed, err := service.NewInfrastructureServiceWithStore(adminUser, store).RekeyInfrastructureStates(true)
+	if err != nil || rekeyed != 4 {
+		t.Fatalf("Current state and 3 versions should be rekeyed, got %d, %v", rekeyed, err)
+	}
+	original, _ := store.GetStateVersionById(plaintext.Id)
+	if !strings.HasPrefix(fmt.Sprint(original.State[eve.STATE_CIPHERTEXT]), "v1:") {
+		t.Errorf("Plaintext version should be encrypted with rotated key, got %v", original.State)
+	}
+}
+
+func TestInfrastructureService_GetInfrastructureOutputs(t *testing.T) {
+	store := memory.NewStore()
+	store.InsertInfrastructure(newInfrastructure("dev", eve.DEPLOYED))
+	store.InsertStateVersion(eve.NewStateVersion("dev", map[string]interface{}{
+		"version": 4.0,
+		"outputs": map[string]interface{}{
+			"dns":      map[string]interface{}{"value": "k8s.example.com", "type": "string"},
+			"password": map[string]interface{}{"value": "secret", "type": "string", "sensitive": true},
+		},
+	}, ownerUser.Id), nil)
+
+	outputs, err := service.NewInfrastructureServiceWithStore(ownerUser, store).GetInfrastructureOutputs("dev")
+	if err != nil || outputs["password"].Value != "secret" {
+		t.Errorf("Owner should read sensitive output, got %v, %v", outputs, err)
+	}
+
+	reader := &eve.User{Id: "bob", Organization: "concur"}
+	outputs, err = service.NewInfrastructureServiceWithStore(reader, store).GetInfrastructureOutputs("dev")
+	if err != nil || outputs["password"].Value != eve.MASKED_OUTPUT || outputs["dns"].Value != "k8s.example.com" {
+		t.Errorf("Sensitive output should be masked without execute permission, got %v, %v", outputs, err)
+	}
+	readerSvc := service.NewInfrastructureServiceWithStore(reader, store)
+	if _, err := readerSvc.GetInfrastructureState("dev"); err == nil {
+		t.Errorf("State should not be readable without execute permission")
+	} else if _, ok := err.(*eve.ForbiddenError); !ok {
+		t.Errorf("Reading state without execute permission should return *eve.ForbiddenError, got %#v", err)
+	}
+	if infra, err := readerSvc.GetInfrastructure("dev"); err != nil || infra.State != nil {
+		t.Errorf("Infrastructure should be returned without state, got %v, %v", infra, err)
+	}
+	versions, _ := readerSvc.GetInfrastructureStateVersions("dev")
+	if _, err := readerSvc.GetInfrastructureStateVersion("dev", versions[0].Id); err == nil {
+		t.Errorf("State version should not be readable without execute permission")
+	}
+
+	if _, err := service.NewInfrastructureServiceWithStore(otherUser, store).GetInfrastructureOutputs("dev"); err == nil {
+		t.Errorf("Unauthorized user should not read outputs")
+	}
+	if outputs, _ := service.NewInfrastructureServiceWithStore(ownerUser, store).GetInfrastructureOutputs("missing"); outputs != nil {
+		t.Errorf("Missing infrastructure should have nil outputs, got %v", outputs)
+	}
+}
+
+func TestInfrastructureService_RecreateRequiresWrite(t *testing.T) {
+	store := memory.NewStore()
+	store.InsertInfrastructure(newInfrastructure("dev", eve.FAILED))
+
+	reader := &eve.User{Id: "bob", Organization: "concur"}
+	infra := newInfrastructure("dev", eve.VALIDATED)
+	infra.ProviderSlug = "aws:prod"
+	if err := service.NewInfrastructureServiceWithStore(reader, store).CreateInfrastructure(infra); err == nil {
+		t.Errorf("Reader should not re-create infrastructure")
+	}
+	if stored, _ := store.GetInfrastructureByName("dev"); stored.Status != eve.FAILED || stored.ProviderSlug != "aws:dev" {
+		t.Errorf("Rejected re-create should not change infrastructure, got %v", stored)
+	}
+}
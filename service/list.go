@@ -0,0 +1,36 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+const (
+	DEFAULT_LIST_LIMIT = 50
+	MAX_LIST_LIMIT     = 500
+)
+
+// EncodeCursor turns the last listed name into an opaque page cursor
+func EncodeCursor(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
+
+// DecodeCursor returns the last listed name of a page cursor. Empty cursor starts from the first page
+func DecodeCursor(cursor string) (string, error) {
+	name, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("Invalid cursor %q", cursor)
+	}
+	return string(name), nil
+}
+
+// listLimit returns page size of limit, applying the default and maximum page sizes
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return DEFAULT_LIST_LIMIT
+	}
+	if limit > MAX_LIST_LIMIT {
+		return MAX_LIST_LIMIT
+	}
+	return limit
+}
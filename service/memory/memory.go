@@ -0,0 +1,621 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/concur/eve"
+	"github.com/pborman/uuid"
+)
+
+// Store is a thread-safe in-memory eve.Store. Records are copied in and out of the store,
+// so callers never share memory with stored records.
+type Store struct {
+	mu              sync.RWMutex
+	quoins          map[string]*eve.Quoin
+	archives        map[string]*eve.QuoinArchive
+	infrastructures map[string]*eve.Infrastructure
+	providers       map[string]*eve.Provider
+	stateVersions   []*eve.StateVersion // in insertion order
+	stateLocks      map[string]*eve.StateLock
+	audits          []*eve.AuditRecord
+}
+
+var _ eve.Store = (*Store)(nil)
+
+// NewStore creates an empty in-memory store
+func NewStore() *Store {
+	return &Store{
+		quoins:          make(map[string]*eve.Quoin),
+		archives:        make(map[string]*eve.QuoinArchive),
+		infrastructures: make(map[string]*eve.Infrastructure),
+		providers:       make(map[string]*eve.Provider),
+		stateLocks:      make(map[string]*eve.StateLock),
+	}
+}
+
+// now returns the current time rounded like rethinkdb's millisecond time precision
+func now() time.Time {
+	return time.Now().UTC().Round(time.Millisecond)
+}
+
+// clone deep copies src into dst through its JSON representation
+func clone(src interface{}, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func (s *Store) InsertQuoin(quoin *eve.Quoin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.quoins[quoin.Name]; ok {
+		return fmt.Errorf("Quoin %s already exists", quoin.Name)
+	}
+	var stored eve.Quoin
+	if err := clone(quoin, &stored); err != nil {
+		return err
+	}
+	stored.Id = eve.NameId(quoin.Name)
+	stored.Status = eve.DEFAULT
+	stored.CreatedAt = now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.quoins[quoin.Name] = &stored
+	return clone(&stored, quoin)
+}
+
+func (s *Store) UpdateQuoin(name string, quoin *eve.Quoin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.quoins[name]
+	if !ok {
+		return nil
+	}
+	var stored eve.Quoin
+	if err := clone(quoin, &stored); err != nil {
+		return err
+	}
+	stored.Id = existing.Id
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = now()
+	s.quoins[name] = &stored
+	return nil
+}
+
+func (s *Store) UpdateQuoinAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if quoin, ok := s.quoins[name]; ok {
+		quoin.Authorization.GrantAccess(group, mode)
+	}
+	return nil
+}
+
+func (s *Store) DeleteQuoinAccess(name string, group eve.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if quoin, ok := s.quoins[name]; ok {
+		delete(quoin.Authorization.GroupAccess, group)
+	}
+	return nil
+}
+
+func (s *Store) UpdateQuoinOwner(name string, from eve.UserId, to eve.UserId) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if quoin, ok := s.quoins[name]; ok {
+		quoin.Authorization.TransferOwnership(from, to)
+	}
+	for _, archive := range s.archives {
+		if archive.QuoinName == name {
+			archive.Authorization.TransferOwnership(from, to)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetQuoinByName(name string) (*eve.Quoin, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.quoins[name]
+	if !ok {
+		return nil, nil
+	}
+	var quoin eve.Quoin
+	if err := clone(stored, &quoin); err != nil {
+		return nil, err
+	}
+	return &quoin, nil
+}
+
+func (s *Store) ListQuoins(options eve.ListOptions) ([]eve.Quoin, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var quoins []eve.Quoin
+	for _, stored := range s.quoins {
+		if !options.MatchQuoin(stored) {
+			continue
+		}
+		var quoin eve.Quoin
+		if err := clone(stored, &quoin); err != nil {
+			return nil, err
+		}
+		quoins = append(quoins, quoin)
+	}
+	sort.Slice(quoins, func(i, j int) bool { return quoins[i].Name < quoins[j].Name })
+	if options.Limit > 0 && len(quoins) > options.Limit {
+		quoins = quoins[:options.Limit]
+	}
+	return quoins, nil
+}
+
+func (s *Store) InsertQuoinArchive(archive *eve.QuoinArchive) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	quoin, ok := s.quoins[archive.QuoinName]
+	if !ok {
+		return fmt.Errorf("Quoin %s doesn't exist", archive.QuoinName)
+	}
+	var stored eve.QuoinArchive
+	if err := clone(archive, &stored); err != nil {
+		return err
+	}
+	stored.Id = uuid.NewRandom().String()
+	stored.CreatedAt = now()
+	s.archives[stored.Id] = &stored
+	archive.Id = stored.Id
+
+	// Point quoin to its latest archive
+	quoin.ArchiveUri = strings.SplitAfter(quoin.ArchiveUri, "/upload")[0] + "/" + stored.Id
+	quoin.Status = eve.VALIDATED
+	quoin.UpdatedAt = now()
+	return nil
+}
+
+func (s *Store) GetQuoinArchiveById(id string) (*eve.QuoinArchive, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.archives[id]
+	if !ok {
+		return nil, nil
+	}
+	var archive eve.QuoinArchive
+	if err := clone(stored, &archive); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+func (s *Store) GetQuoinArchivesByQuoin(quoinName string) ([]eve.QuoinArchive, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var archives []eve.QuoinArchive
+	for _, stored := range s.archives {
+		if stored.QuoinName != quoinName {
+			continue
+		}
+		var archive eve.QuoinArchive
+		if err := clone(stored, &archive); err != nil {
+			return nil, err
+		}
+		archive.Modules = nil
+		archives = append(archives, archive)
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].CreatedAt.After(archives[j].CreatedAt) })
+	return archives, nil
+}
+
+func (s *Store) DeleteQuoinArchive(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.archives, id)
+	return nil
+}
+
+func (s *Store) InsertInfrastructure(infra *eve.Infrastructure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.infrastructures[infra.Name]; ok {
+		return fmt.Errorf("Infrastructure %s already exists", infra.Name)
+	}
+	var stored eve.Infrastructure
+	if err := clone(infra, &stored); err != nil {
+		return err
+	}
+	stored.Id = eve.NameId(infra.Name)
+	stored.State = nil
+	stored.CreatedAt = now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.infrastructures[infra.Name] = &stored
+	return nil
+}
+
+func (s *Store) UpdateInfrastructureState(name string, state map[string]interface{}, condition *eve.StateCondition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infra, ok := s.infrastructures[name]
+	if !ok {
+		return nil
+	}
+	return s.updateInfrastructureState(infra, state, condition)
+}
+
+// updateInfrastructureState replaces infrastructure's state when condition accepts it. s.mu must be locked,
+// so the state's lock cannot change before the state is written
+func (s *Store) updateInfrastructureState(infra *eve.Infrastructure, state map[string]interface{}, condition *eve.StateCondition) error {
+	var lock *eve.StateLock
+	if stored, ok := s.stateLocks[infra.Name]; ok {
+		locked := *stored
+		lock = &locked
+	}
+	if err := condition.Check(infra.Name, lock, infra.State, state); err != nil {
+		return err
+	}
+	var stored map[string]interface{}
+	if err := clone(state, &stored); err != nil {
+		return err
+	}
+	infra.State = stored
+	infra.UpdatedAt = now()
+	return nil
+}
+
+func (s *Store) UpdateInfrastructureStatus(name string, status eve.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infra, err := s.transitInfrastructure(name, status)
+	if err != nil {
+		return err
+	}
+	infra.Status = status
+	return nil
+}
+
+func (s *Store) UpdateInfrastructureError(name string, infraError error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if infraError == nil {
+		if infra, ok := s.infrastructures[name]; ok {
+			infra.Error = ""
+			infra.UpdatedAt = now()
+		}
+		return nil
+	}
+	infra, err := s.transitInfrastructure(name, eve.FAILED)
+	if err != nil {
+		return err
+	}
+	infra.Status = eve.FAILED
+	infra.Error = infraError.Error()
+	return nil
+}
+
+// transitInfrastructure returns the stored infrastructure when it can move to status to. Caller must hold the lock.
+func (s *Store) transitInfrastructure(name string, to eve.Status) (*eve.Infrastructure, error) {
+	infra, ok := s.infrastructures[name]
+	if !ok {
+		return nil, fmt.Errorf("Infrastructure %s doesn't exist", name)
+	}
+	if !infra.Status.CanTransitionTo(to) {
+		return nil, &eve.TransitionError{Name: name, From: infra.Status, To: to}
+	}
+	infra.RecordTransition(to, now())
+	return infra, nil
+}
+
+func (s *Store) UpdateInfrastructureAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if infra, ok := s.infrastructures[name]; ok {
+		infra.Authorization.GrantAccess(group, mode)
+	}
+	return nil
+}
+
+func (s *Store) DeleteInfrastructureAccess(name string, group eve.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if infra, ok := s.infrastructures[name]; ok {
+		delete(infra.Authorization.GroupAccess, group)
+	}
+	return nil
+}
+
+func (s *Store) UpdateInfrastructureOwner(name string, from eve.UserId, to eve.UserId) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if infra, ok := s.infrastructures[name]; ok {
+		infra.Authorization.TransferOwnership(from, to)
+	}
+	return nil
+}
+
+func (s *Store) GetInfrastructureByName(name string) (*eve.Infrastructure, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.infrastructures[name]
+	if !ok {
+		return nil, nil
+	}
+	var infra eve.Infrastructure
+	if err := clone(stored, &infra); err != nil {
+		return nil, err
+	}
+	return &infra, nil
+}
+
+func (s *Store) GetInfrastructuresByQuoin(name string) ([]eve.Infrastructure, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var infras []eve.Infrastructure
+	for _, stored := range s.infrastructures {
+		if stored.Status == eve.DESTROYED || stored.Quoin == nil || stored.Quoin.Name != name {
+			continue
+		}
+		var infra eve.Infrastructure
+		if err := clone(stored, &infra); err != nil {
+			return nil, err
+		}
+		infra.State = nil
+		infras = append(infras, infra)
+	}
+	sort.Slice(infras, func(i, j int) bool { return infras[i].Name < infras[j].Name })
+	return infras, nil
+}
+
+func (s *Store) ListInfrastructures(options eve.ListOptions) ([]eve.Infrastructure, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var infras []eve.Infrastructure
+	for _, stored := range s.infrastructures {
+		if !options.MatchInfrastructure(stored) {
+			continue
+		}
+		var infra eve.Infrastructure
+		if err := clone(stored, &infra); err != nil {
+			return nil, err
+		}
+		infra.State = nil
+		infras = append(infras, infra)
+	}
+	sort.Slice(infras, func(i, j int) bool { return infras[i].Name < infras[j].Name })
+	if options.Limit > 0 && len(infras) > options.Limit {
+		infras = infras[:options.Limit]
+	}
+	return infras, nil
+}
+
+func (s *Store) InsertStateVersion(version *eve.StateVersion, condition *eve.StateCondition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infra, ok := s.infrastructures[version.InfrastructureName]
+	if !ok {
+		return fmt.Errorf("Infrastructure %s doesn't exist", version.InfrastructureName)
+	}
+	if err := s.updateInfrastructureState(infra, version.State, condition); err != nil {
+		return err
+	}
+
+	var stored eve.StateVersion
+	if err := clone(version, &stored); err != nil {
+		return err
+	}
+	stored.Id = uuid.NewRandom().String()
+	stored.CreatedAt = infra.UpdatedAt
+	s.stateVersions = append(s.stateVersions, &stored)
+	version.Id, version.CreatedAt = stored.Id, stored.CreatedAt
+	return nil
+}
+
+func (s *Store) GetStateVersionById(id string) (*eve.StateVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, stored := range s.stateVersions {
+		if stored.Id == id {
+			var version eve.StateVersion
+			if err := clone(stored, &version); err != nil {
+				return nil, err
+			}
+			return &version, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) GetStateVersionsByInfrastructure(name string) ([]eve.StateVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var versions []eve.StateVersion
+	for i := len(s.stateVersions) - 1; i >= 0; i-- {
+		stored := s.stateVersions[i]
+		if stored.InfrastructureName != name {
+			continue
+		}
+		version := *stored
+		version.State = nil
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (s *Store) UpdateStateVersionState(id string, state map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range s.stateVersions {
+		if stored.Id == id {
+			var cloned map[string]interface{}
+			if err := clone(state, &cloned); err != nil {
+				return err
+			}
+			stored.State = cloned
+			return nil
+		}
+	}
+	return fmt.Errorf("State version %s not found", id)
+}
+
+func (s *Store) InsertStateLock(lock *eve.StateLock) (*eve.StateLock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.stateLocks[lock.InfrastructureName]; ok {
+		locked := *existing
+		return &locked, nil
+	}
+	stored := *lock
+	stored.Created = now()
+	s.stateLocks[lock.InfrastructureName] = &stored
+	lock.Created = stored.Created
+	return nil, nil
+}
+
+func (s *Store) GetStateLock(name string) (*eve.StateLock, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.stateLocks[name]
+	if !ok {
+		return nil, nil
+	}
+	lock := *stored
+	return &lock, nil
+}
+
+func (s *Store) DeleteStateLock(name string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.stateLocks[name]; ok && (id == "" || stored.ID == id) {
+		delete(s.stateLocks, name)
+	}
+	return nil
+}
+
+func (s *Store) InsertProvider(provider *eve.Provider) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.providers[provider.Name]; ok {
+		return fmt.Errorf("Provider %s already exists", provider.Name)
+	}
+	var stored eve.Provider
+	if err := clone(provider, &stored); err != nil {
+		return err
+	}
+	stored.Id = eve.NameId(provider.Name)
+	stored.CreatedAt = now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.providers[provider.Name] = &stored
+	provider.Id = stored.Id
+	return nil
+}
+
+func (s *Store) UpdateProvider(name string, provider *eve.Provider) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.providers[name]
+	if !ok {
+		return nil
+	}
+	var schema eve.Schema
+	if err := clone(provider.Schema, &schema); err != nil {
+		return err
+	}
+	stored.Schema = schema
+	stored.UpdatedAt = now()
+	return nil
+}
+
+func (s *Store) UpdateProviderAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if provider, ok := s.providers[name]; ok {
+		provider.Authorization.GrantAccess(group, mode)
+		provider.UpdatedAt = now()
+	}
+	return nil
+}
+
+func (s *Store) DeleteProviderAccess(name string, group eve.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if provider, ok := s.providers[name]; ok {
+		delete(provider.Authorization.GroupAccess, group)
+		provider.UpdatedAt = now()
+	}
+	return nil
+}
+
+func (s *Store) DeleteProvider(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.providers, name)
+	return nil
+}
+
+func (s *Store) GetProviderByName(name string) (*eve.Provider, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.providers[name]
+	if !ok {
+		return nil, nil
+	}
+	var provider eve.Provider
+	if err := clone(stored, &provider); err != nil {
+		return nil, err
+	}
+	return &provider, nil
+}
+
+func (s *Store) GetProviders() ([]eve.Provider, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var providers []eve.Provider
+	for _, stored := range s.providers {
+		var provider eve.Provider
+		if err := clone(stored, &provider); err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers, nil
+}
+
+func (s *Store) CountInfrastructuresByProvider(name string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := 0
+	for _, infra := range s.infrastructures {
+		if infra.Status < eve.DESTROYED && strings.HasPrefix(infra.ProviderSlug, name+":") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) InsertAuditRecord(record *eve.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stored eve.AuditRecord
+	if err := clone(record, &stored); err != nil {
+		return err
+	}
+	stored.Id = uuid.NewRandom().String()
+	s.audits = append(s.audits, &stored)
+	record.Id = stored.Id
+	return nil
+}
+
+// AuditRecords returns copies of stored audit records in insertion order
+func (s *Store) AuditRecords() []eve.AuditRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]eve.AuditRecord, 0, len(s.audits))
+	for _, stored := range s.audits {
+		records = append(records, *stored)
+	}
+	return records
+}
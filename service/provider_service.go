@@ -1,12 +1,18 @@
 package service
 
 import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
-	"github.com/concur/eve/service/rethinkdb"
+	eveProvider "github.com/concur/eve/provider"
+	_ "github.com/concur/eve/provider/aws"
+	_ "github.com/concur/eve/provider/gcp"
 )
 
 type ProviderService struct {
 	*eve.User
+	store eve.Store
 }
 
 func NewProviderService(user *eve.User) *ProviderService {
@@ -15,12 +21,239 @@ func NewProviderService(user *eve.User) *ProviderService {
 	}
 }
 
+// NewProviderServiceWithStore creates ProviderService using the given store instead of the default store
+func NewProviderServiceWithStore(user *eve.User, store eve.Store) *ProviderService {
+	return &ProviderService{
+		User:  user,
+		store: store,
+	}
+}
+
+func (p ProviderService) db() eve.Store {
+	return resolveStore(p.store)
+}
+
 // GetProvider returns Provider information from database
 func (p ProviderService) GetProvider(name string) (*eve.Provider, error) {
-	db := rethinkdb.DefaultSession()
+	db := p.db()
 	provider, err := db.GetProviderByName(name)
 	if err != nil {
 		return nil, err
 	}
+
+	if provider == nil {
+		return nil, nil
+	}
+
+	if !provider.AuthorizedRead(p.User) {
+		return nil, &eve.ForbiddenError{User: p.User.Id, Action: "read provider " + provider.Name}
+	}
+
 	return provider, nil
 }
+
+// GetProviders returns the providers which user is authorized to read
+func (p ProviderService) GetProviders() ([]eve.Provider, error) {
+	db := p.db()
+	providers, err := db.GetProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	authorized := make([]eve.Provider, 0, len(providers))
+	for _, provider := range providers {
+		if provider.AuthorizedRead(p.User) {
+			authorized = append(authorized, provider)
+		}
+	}
+	return authorized, nil
+}
+
+// CreateProvider stores a new provider on database
+func (p ProviderService) CreateProvider(provider *eve.Provider) error {
+	if err := eveProvider.Validate(provider); err != nil {
+		return err
+	}
+
+	db := p.db()
+	existing, err := db.GetProviderByName(provider.Name)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("Provider %s already exists", provider.Name)
+	}
+
+	if err := db.InsertProvider(provider); err != nil {
+		return err
+	}
+	log.Printf("New provider %s is stored in eve db.", provider.Name)
+	return nil
+}
+
+// UpdateProvider replaces the provider's schema
+func (p ProviderService) UpdateProvider(name string, provider *eve.Provider) error {
+	if err := p.checkWritePermission(name); err != nil {
+		return err
+	}
+
+	provider.Name = name
+	if err := eveProvider.Validate(provider); err != nil {
+		return err
+	}
+
+	db := p.db()
+	if err := db.UpdateProvider(name, provider); err != nil {
+		return err
+	}
+	log.Printf("Provider %s is updated by user %s.", name, p.User.Id)
+	return nil
+}
+
+// DeleteProvider removes the provider when no infrastructure is using it
+func (p ProviderService) DeleteProvider(name string) error {
+	if err := p.checkWritePermission(name); err != nil {
+		return err
+	}
+
+	db := p.db()
+	count, err := db.CountInfrastructuresByProvider(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("Provider %s is still used by infrastructure and cannot be deleted", name)
+	}
+
+	if err := db.DeleteProvider(name); err != nil {
+		return err
+	}
+	log.Printf("Provider %s is deleted by user %s.", name, p.User.Id)
+	return nil
+}
+
+// UpdateProviderAccess grants the group given policy mode on the provider, e.g. execute to let the group
+// create infrastructures on provider's accounts
+func (p ProviderService) UpdateProviderAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	if err := p.checkSharePermission(name); err != nil {
+		return err
+	}
+
+	db := p.db()
+	if err := db.UpdateProviderAccess(name, group, mode); err != nil {
+		return err
+	}
+
+	log.Printf("User %s grants %s access %s on provider %s", p.User.Id, group, mode, name)
+	return nil
+}
+
+// DeleteProviderAccess revokes the group's access on the provider
+func (p ProviderService) DeleteProviderAccess(name string, group eve.Group) error {
+	if err := p.checkSharePermission(name); err != nil {
+		return err
+	}
+
+	db := p.db()
+	if err := db.DeleteProviderAccess(name, group); err != nil {
+		return err
+	}
+
+	log.Printf("User %s revokes %s access on provider %s", p.User.Id, group, name)
+	return nil
+}
+
+func (p ProviderService) checkSharePermission(name string) error {
+	db := p.db()
+	provider, err := db.GetProviderByName(name)
+	if err != nil {
+		return err
+	}
+
+	if provider == nil {
+		return fmt.Errorf("Provider %s doesn't exist", name)
+	}
+
+	if !provider.Authorization.AuthorizedShare(p.User) {
+		return &eve.ForbiddenError{User: p.User.Id, Action: "change access of provider " + name}
+	}
+	return nil
+}
+
+func (p ProviderService) checkWritePermission(name string) error {
+	db := p.db()
+	provider, err := db.GetProviderByName(name)
+	if err != nil {
+		return err
+	}
+
+	if provider == nil {
+		return fmt.Errorf("Provider %s doesn't exist", name)
+	}
+
+	if !provider.AuthorizedWrite(p.User) {
+		return &eve.ForbiddenError{User: p.User.Id, Action: "modify provider " + name}
+	}
+	return nil
+}
+
+// CheckExecutePermission validates that user can use the provider and account referenced by provider slug
+func (p ProviderService) CheckExecutePermission(providerSlug string) error {
+	_, err := p.GetAuthorizedAccount(providerSlug)
+	return err
+}
+
+// GetAuthorizedAccount returns the account referenced by provider slug when user can use the provider and account
+func (p ProviderService) GetAuthorizedAccount(providerSlug string) (*eveProvider.Account, error) {
+	providerName, accountName, err := eve.ParseProviderSlug(providerSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	db := p.db()
+	provider, err := db.GetProviderByName(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider == nil {
+		return nil, fmt.Errorf("Provider %s doesn't exist", providerName)
+	}
+
+	if !provider.AuthorizedExecute(p.User) {
+		return nil, &eve.ForbiddenError{User: p.User.Id, Action: "use provider " + providerName}
+	}
+
+	account, err := eveProvider.FindAccount(provider, accountName)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, fmt.Errorf("Account %s doesn't exist in provider %s", accountName, providerName)
+	}
+
+	if account.Authorization != nil && !account.Authorization.DefaultAuthorizedExecute(p.User) {
+		return nil, &eve.ForbiddenError{User: p.User.Id, Action: fmt.Sprintf("use account %s of provider %s", accountName, providerName)}
+	}
+
+	return account, nil
+}
+
+// NewCredentials builds terraform credentials for the account referenced by provider slug
+func (p ProviderService) NewCredentials(providerSlug string, options eveProvider.Options) (eveProvider.Credentials, error) {
+	providerName, accountName, err := eve.ParseProviderSlug(providerSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := p.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("Provider %s doesn't exist", providerName)
+	}
+
+	return eveProvider.NewCredentials(provider, accountName, options)
+}
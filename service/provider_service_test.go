@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/service"
+	"github.com/concur/eve/service/memory"
+)
+
+var (
+	adminUser = &eve.User{Id: "root", Organization: "concur", Admin: true}
+	ownerUser = &eve.User{Id: "alice", Organization: "concur"}
+	otherUser = &eve.User{Id: "dave", Organization: "acme"}
+)
+
+func newAwsProvider(name string) *eve.Provider {
+	return &eve.Provider{
+		Name: name,
+		Schema: eve.Schema{
+			Type: "aws",
+			Data: []interface{}{
+				map[string]interface{}{
+					"Name":     "dev",
+					"Id":       float64(123456789012),
+					"Roles":    []interface{}{"eve-deployer"},
+					"Regions":  []interface{}{"us-west-2"},
+					"AuthType": float64(1),
+				},
+			},
+		},
+		Authorization: eve.Authorization{
+			Owner: ownerUser.Id,
+			GroupAccess: map[eve.Group]eve.PolicyMode{
+				eve.Group(ownerUser.Id): eve.POLICY_ALL,
+				eve.Group("concur"):     eve.POLICY_READ_EXECUTE,
+			},
+		},
+	}
+}
+
+func TestProviderService_CRUD(t *testing.T) {
+	store := memory.NewStore()
+	providerSvc := service.NewProviderServiceWithStore(adminUser, store)
+
+	if err := providerSvc.CreateProvider(newAwsProvider("aws")); err != nil {
+		t.Fatalf("CreateProvider returns error: %v", err)
+	}
+	if err := providerSvc.CreateProvider(newAwsProvider("aws")); err == nil {
+		t.Errorf("Duplicated provider should be rejected")
+	}
+
+	provider, err := service.NewProviderServiceWithStore(ownerUser, store).GetProvider("aws")
+	if err != nil || provider == nil {
+		t.Fatalf("Owner should read provider: %v", err)
+	}
+	if _, err := service.NewProviderServiceWithStore(otherUser, store).GetProvider("aws"); err == nil {
+		t.Errorf("Outsider should not read provider")
+	}
+	if providers, _ := service.NewProviderServiceWithStore(otherUser, store).GetProviders(); len(providers) != 0 {
+		t.Errorf("Outsider should not list provider, got %v", providers)
+	}
+
+	if err := service.NewProviderServiceWithStore(ownerUser, store).CheckExecutePermission("aws:dev"); err != nil {
+		t.Errorf("Owner should use provider account: %v", err)
+	}
+	if err := service.NewProviderServiceWithStore(ownerUser, store).CheckExecutePermission("aws:prod"); err == nil {
+		t.Errorf("Unknown account should be rejected")
+	}
+
+	if err := providerSvc.DeleteProvider("aws"); err != nil {
+		t.Fatalf("DeleteProvider returns error: %v", err)
+	}
+	if provider, _ := providerSvc.GetProvider("aws"); provider != nil {
+		t.Errorf("Provider should be deleted")
+	}
+}
+
+func TestProviderService_DeleteProviderInUse(t *testing.T) {
+	store := memory.NewStore()
+	providerSvc := service.NewProviderServiceWithStore(adminUser, store)
+	if err := providerSvc.CreateProvider(newAwsProvider("aws")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.InsertInfrastructure(&eve.Infrastructure{Name: "dev", ProviderSlug: "aws:dev", Status: eve.DEPLOYED}); err != nil {
+		t.Fatal(err)
+	}
+	if err := providerSvc.DeleteProvider("aws"); err == nil {
+		t.Errorf("Provider used by infrastructure should not be deleted")
+	}
+}
+
+func TestProviderService_Access(t *testing.T) {
+	store := memory.NewStore()
+	provider := newAwsProvider("aws")
+	provider.Authorization = eve.Authorization{
+		Owner:       adminUser.Id,
+		GroupAccess: map[eve.Group]eve.PolicyMode{eve.Group(adminUser.Id): eve.POLICY_ALL},
+	}
+	if err := service.NewProviderServiceWithStore(adminUser, store).CreateProvider(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	ownerSvc := service.NewProviderServiceWithStore(ownerUser, store)
+	if _, ok := ownerSvc.CheckExecutePermission("aws:dev").(*eve.ForbiddenError); !ok {
+		t.Errorf("Provider should not be usable without granted access")
+	}
+	if _, ok := ownerSvc.UpdateProviderAccess("aws", eve.Group("concur"), eve.POLICY_EXECUTE).(*eve.ForbiddenError); !ok {
+		t.Errorf("Only provider owner or admin users should grant access")
+	}
+	if err := service.NewProviderServiceWithStore(adminUser, store).UpdateProviderAccess("aws", eve.Group("concur"), eve.POLICY_READ_EXECUTE); err != nil {
+		t.Fatalf("UpdateProviderAccess returns error: %v", err)
+	}
+	if err := ownerSvc.CheckExecutePermission("aws:dev"); err != nil {
+		t.Errorf("Granted group should use provider account: %v", err)
+	}
+	if err := service.NewProviderServiceWithStore(adminUser, store).DeleteProviderAccess("aws", eve.Group("concur")); err != nil {
+		t.Fatalf("DeleteProviderAccess returns error: %v", err)
+	}
+	if _, ok := ownerSvc.CheckExecutePermission("aws:dev").(*eve.ForbiddenError); !ok {
+		t.Errorf("Revoked group should not use provider account")
+	}
+}
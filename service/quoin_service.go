@@ -1,17 +1,19 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
 	"github.com/concur/eve/pkg/terraform"
-	"github.com/concur/eve/service/rethinkdb"
 )
 
 type QuoinService struct {
 	*eve.User
+	store eve.Store
 }
 
 func NewQuoinService(user *eve.User) *QuoinService {
@@ -20,9 +22,21 @@ func NewQuoinService(user *eve.User) *QuoinService {
 	}
 }
 
+// NewQuoinServiceWithStore creates QuoinService using the given store instead of the default store
+func NewQuoinServiceWithStore(user *eve.User, store eve.Store) *QuoinService {
+	return &QuoinService{
+		User:  user,
+		store: store,
+	}
+}
+
+func (q QuoinService) db() eve.Store {
+	return resolveStore(q.store)
+}
+
 // GetQuoin returns Quoin information from database
 func (q QuoinService) GetQuoin(name string) (*eve.Quoin, error) {
-	db := rethinkdb.DefaultSession()
+	db := q.db()
 	quoin, err := db.GetQuoinByName(name)
 	if err != nil {
 		return nil, err
@@ -39,9 +53,33 @@ func (q QuoinService) GetQuoin(name string) (*eve.Quoin, error) {
 	return quoin, nil
 }
 
+// ListQuoins returns a page of quoins matching options. If user is not authorized
+// to read a quoin, only the quoin's name is returned
+func (q QuoinService) ListQuoins(options eve.ListOptions) (*eve.QuoinList, error) {
+	limit := listLimit(options.Limit)
+	options.Limit = limit + 1
+	quoins, err := q.db().ListQuoins(options)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &eve.QuoinList{Items: []eve.Quoin{}}
+	if len(quoins) > limit {
+		quoins = quoins[:limit]
+		list.Next = EncodeCursor(quoins[limit-1].Name)
+	}
+	for _, quoin := range quoins {
+		if !quoin.AuthorizedRead(q.User) {
+			quoin = eve.Quoin{Name: quoin.Name}
+		}
+		list.Items = append(list.Items, quoin)
+	}
+	return list, nil
+}
+
 // GetQuoinArchive returns Quoin archive module from database
 func (q QuoinService) GetQuoinArchive(id string) (*eve.QuoinArchive, error) {
-	db := rethinkdb.DefaultSession()
+	db := q.db()
 	quoinArchive, err := db.GetQuoinArchiveById(id)
 	if err != nil {
 		return nil, err
@@ -57,9 +95,40 @@ func (q QuoinService) GetQuoinArchive(id string) (*eve.QuoinArchive, error) {
 	return quoinArchive, nil
 }
 
-// GetQuoinArchives returns list of quoin archive modules from database
+// GetQuoinArchives returns quoin's archives without their modules, newest first.
+// It returns nil when the quoin doesn't exist
+func (q QuoinService) GetQuoinArchives(quoinName string) ([]eve.QuoinArchive, error) {
+	quoin, err := q.GetQuoin(quoinName)
+	if err != nil || quoin == nil {
+		return nil, err
+	}
+
+	db := q.db()
+	quoinArchives, err := db.GetQuoinArchivesByQuoin(quoinName)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := make([]eve.QuoinArchive, 0, len(quoinArchives))
+	for _, quoinArchive := range quoinArchives {
+		if quoinArchive.AuthorizedRead(q.User) {
+			readable = append(readable, quoinArchive)
+		}
+	}
+	return readable, nil
+}
+
+// GetQuoinArchiveIds returns ids of quoin's archives, newest first
 func (q QuoinService) GetQuoinArchiveIds(quoinName string) ([]string, error) {
-	return []string{}, nil
+	quoinArchives, err := q.GetQuoinArchives(quoinName)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(quoinArchives))
+	for _, quoinArchive := range quoinArchives {
+		ids = append(ids, quoinArchive.Id)
+	}
+	return ids, nil
 }
 
 func (q QuoinService) GetQuoinArchiveIdFromUri(archiveUri string) string {
@@ -73,7 +142,7 @@ func (q QuoinService) GetQuoinArchiveIdFromUri(archiveUri string) string {
 
 // CreateQuoin creates Quoin record on database and calls CreateQuoinArchive
 func (q QuoinService) CreateQuoin(quoin *eve.Quoin) (*eve.Quoin, error) {
-	db := rethinkdb.DefaultSession()
+	db := q.db()
 	qu, err := db.GetQuoinByName(quoin.Name)
 	if err != nil {
 		return nil, err
@@ -106,7 +175,11 @@ func (q QuoinService) CreateQuoinArchive(quoinArchive *eve.QuoinArchive) error {
 		return err
 	}
 	log.Printf("Quoin Archive for %s is valid. Terraform plan has been generated.", quoinArchive.QuoinName)
-	db := rethinkdb.DefaultSession()
+	sum := sha256.Sum256(quoinArchive.Modules)
+	quoinArchive.Uploader = q.User.Id
+	quoinArchive.Size = len(quoinArchive.Modules)
+	quoinArchive.Sha256 = hex.EncodeToString(sum[:])
+	db := q.db()
 	if err := db.InsertQuoinArchive(quoinArchive); err != nil {
 		return err
 	}
@@ -115,7 +188,7 @@ func (q QuoinService) CreateQuoinArchive(quoinArchive *eve.QuoinArchive) error {
 }
 
 func (q QuoinService) DeleteQuoin(name string) error {
-	db := rethinkdb.DefaultSession()
+	db := q.db()
 	quoin, err := db.GetQuoinByName(name)
 	if err != nil {
 		return err
@@ -129,7 +202,7 @@ func (q QuoinService) DeleteQuoin(name string) error {
 		return fmt.Errorf("User %s is not authorized to delete Quoin %s", q.User.Id, name)
 	}
 
-	infraSvc := NewInfrastructureService(q.User)
+	infraSvc := NewInfrastructureServiceWithStore(q.User, q.store)
 	count, err := infraSvc.CountInfrastructureByQuoin(name)
 	if err != nil {
 		return err
@@ -148,7 +221,192 @@ func (q QuoinService) DeleteQuoin(name string) error {
 	return nil
 }
 
+// DeleteQuoinArchive deletes the archive. It returns *eve.ArchiveInUseError while the archive is
+// referenced by a not yet destroyed infrastructure, or is the current archive of an active quoin
 func (q QuoinService) DeleteQuoinArchive(id string) error {
-	// Can not delete the Quoin if it is still in used by a quoin
+	db := q.db()
+	quoinArchive, err := db.GetQuoinArchiveById(id)
+	if err != nil {
+		return err
+	}
+
+	if quoinArchive == nil {
+		return fmt.Errorf("Quoin archive %s doesn't exist", id)
+	}
+
+	if !quoinArchive.AuthorizedWrite(q.User) {
+		return fmt.Errorf("User %s is not authorized to delete Quoin archive %s", q.User.Id, id)
+	}
+
+	quoin, err := db.GetQuoinByName(quoinArchive.QuoinName)
+	if err != nil {
+		return err
+	}
+	if quoin != nil && quoin.Status != eve.OBSOLETED && q.GetQuoinArchiveIdFromUri(quoin.ArchiveUri) == id {
+		return &eve.ArchiveInUseError{Id: id, QuoinName: quoinArchive.QuoinName}
+	}
+
+	references, err := q.archiveReferences(quoinArchive.QuoinName)
+	if err != nil {
+		return err
+	}
+	if infras := references[id]; len(infras) > 0 {
+		return &eve.ArchiveInUseError{Id: id, QuoinName: quoinArchive.QuoinName, Infrastructures: infras}
+	}
+
+	if err := db.DeleteQuoinArchive(id); err != nil {
+		return err
+	}
+	log.Printf("User %s deletes archive %s of Quoin %s", q.User.Id, id, quoinArchive.QuoinName)
+	return nil
+}
+
+// CollectQuoinArchives deletes archives of obsoleted quoins which no infrastructure references,
+// and returns them. With dryRun, archives are only returned. Only eve admin users can collect archives
+func (q QuoinService) CollectQuoinArchives(dryRun bool) ([]eve.QuoinArchive, error) {
+	if !q.User.IsAdmin() {
+		return nil, fmt.Errorf("User %s is not authorized to collect Quoin archives", q.User.Id)
+	}
+
+	db := q.db()
+	obsoleted := eve.OBSOLETED
+	options := eve.ListOptions{Status: &obsoleted, Limit: MAX_LIST_LIMIT}
+	collected := []eve.QuoinArchive{}
+	for {
+		quoins, err := db.ListQuoins(options)
+		if err != nil {
+			return collected, err
+		}
+		for _, quoin := range quoins {
+			archives, err := q.unreferencedArchives(quoin.Name)
+			if err != nil {
+				return collected, err
+			}
+			for _, archive := range archives {
+				if !dryRun {
+					if err := db.DeleteQuoinArchive(archive.Id); err != nil {
+						return collected, err
+					}
+					log.Printf("Archive %s of obsoleted Quoin %s is deleted", archive.Id, quoin.Name)
+				}
+				collected = append(collected, archive)
+			}
+		}
+		if len(quoins) < options.Limit {
+			return collected, nil
+		}
+		options.After = quoins[len(quoins)-1].Name
+	}
+}
+
+// unreferencedArchives returns quoin's archives which no infrastructure references
+func (q QuoinService) unreferencedArchives(quoinName string) ([]eve.QuoinArchive, error) {
+	archives, err := q.db().GetQuoinArchivesByQuoin(quoinName)
+	if err != nil {
+		return nil, err
+	}
+	references, err := q.archiveReferences(quoinName)
+	if err != nil {
+		return nil, err
+	}
+	unreferenced := []eve.QuoinArchive{}
+	for _, archive := range archives {
+		if len(references[archive.Id]) == 0 {
+			unreferenced = append(unreferenced, archive)
+		}
+	}
+	return unreferenced, nil
+}
+
+// archiveReferences maps archive ids of the quoin to not yet destroyed infrastructures pinned to them
+// through Quoin.ArchiveUri
+func (q QuoinService) archiveReferences(quoinName string) (map[string][]string, error) {
+	infras, err := q.db().GetInfrastructuresByQuoin(quoinName)
+	if err != nil {
+		return nil, err
+	}
+	references := make(map[string][]string)
+	for _, infra := range infras {
+		if infra.Quoin == nil {
+			continue
+		}
+		id := q.GetQuoinArchiveIdFromUri(infra.Quoin.ArchiveUri)
+		references[id] = append(references[id], infra.Name)
+	}
+	return references, nil
+}
+
+// UpdateQuoinAccess grants the group given policy mode on the quoin
+func (q QuoinService) UpdateQuoinAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	if err := q.checkSharePermission(name); err != nil {
+		return err
+	}
+
+	db := q.db()
+	if err := db.UpdateQuoinAccess(name, group, mode); err != nil {
+		return err
+	}
+
+	log.Printf("User %s grants %s access %s on Quoin %s", q.User.Id, group, mode, name)
+	return nil
+}
+
+// DeleteQuoinAccess revokes the group's access on the quoin
+func (q QuoinService) DeleteQuoinAccess(name string, group eve.Group) error {
+	if err := q.checkSharePermission(name); err != nil {
+		return err
+	}
+
+	db := q.db()
+	if err := db.DeleteQuoinAccess(name, group); err != nil {
+		return err
+	}
+
+	log.Printf("User %s revokes %s access on Quoin %s", q.User.Id, group, name)
+	return nil
+}
+
+// TransferQuoinOwnership changes the owner of quoin and its archives. Only eve admin users can transfer ownership
+func (q QuoinService) TransferQuoinOwnership(name string, owner eve.UserId) error {
+	if owner == "" {
+		return fmt.Errorf("New owner of Quoin %s is missing", name)
+	}
+
+	db := q.db()
+	quoin, err := db.GetQuoinByName(name)
+	if err != nil {
+		return err
+	}
+
+	if quoin == nil {
+		return fmt.Errorf("Quoin %s doesn't exist", name)
+	}
+
+	if !quoin.Authorization.AuthorizedTransfer(q.User) {
+		return &eve.ForbiddenError{User: q.User.Id, Action: "transfer ownership of Quoin " + name}
+	}
+
+	previous := quoin.Authorization.Owner
+	if err := db.UpdateQuoinOwner(name, previous, owner); err != nil {
+		return err
+	}
+
+	return auditOwnershipTransfer(db, "quoin", name, q.User.Id, previous, owner)
+}
+
+func (q QuoinService) checkSharePermission(name string) error {
+	db := q.db()
+	quoin, err := db.GetQuoinByName(name)
+	if err != nil {
+		return err
+	}
+
+	if quoin == nil {
+		return fmt.Errorf("Quoin %s doesn't exist", name)
+	}
+
+	if !quoin.Authorization.AuthorizedShare(q.User) {
+		return &eve.ForbiddenError{User: q.User.Id, Action: "change access of Quoin " + name}
+	}
 	return nil
 }
@@ -0,0 +1,232 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/service"
+	"github.com/concur/eve/service/memory"
+)
+
+func newQuoin(name string) *eve.Quoin {
+	return &eve.Quoin{
+		Name:       name,
+		ArchiveUri: "http://localhost:8088/quoin/" + name + "/upload",
+		Authorization: eve.Authorization{
+			Owner: ownerUser.Id,
+			GroupAccess: map[eve.Group]eve.PolicyMode{
+				eve.Group(ownerUser.Id): eve.POLICY_ALL,
+			},
+		},
+	}
+}
+
+func TestQuoinService_CreateAndDelete(t *testing.T) {
+	store := memory.NewStore()
+	quoinSvc := service.NewQuoinServiceWithStore(ownerUser, store)
+
+	quoin, err := quoinSvc.CreateQuoin(newQuoin("k8s"))
+	if err != nil {
+		t.Fatalf("CreateQuoin returns error: %v", err)
+	}
+	if quoin.Id == "" {
+		t.Errorf("Created quoin should have an id")
+	}
+	if _, err := quoinSvc.CreateQuoin(newQuoin("k8s")); err == nil {
+		t.Errorf("Duplicated quoin should be rejected")
+	}
+	if _, err := service.NewQuoinServiceWithStore(otherUser, store).GetQuoin("k8s"); err == nil {
+		t.Errorf("Outsider should not read quoin")
+	}
+
+	if err := store.InsertInfrastructure(&eve.Infrastructure{Name: "dev", Quoin: &eve.Quoin{Name: "k8s"}, Status: eve.DEPLOYED}); err != nil {
+		t.Fatal(err)
+	}
+	if err := quoinSvc.DeleteQuoin("k8s"); err == nil {
+		t.Errorf("Quoin used by infrastructure should not be deleted")
+	}
+	if err := store.UpdateInfrastructureStatus("dev", eve.RUNNING); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateInfrastructureStatus("dev", eve.DESTROYED); err != nil {
+		t.Fatal(err)
+	}
+	if err := quoinSvc.DeleteQuoin("k8s"); err != nil {
+		t.Fatalf("DeleteQuoin returns error: %v", err)
+	}
+	if quoin, _ := quoinSvc.GetQuoin("k8s"); quoin.Status != eve.OBSOLETED {
+		t.Errorf("Deleted quoin should be obsoleted, got %s", quoin.Status)
+	}
+}
+
+func TestQuoinService_AccessAndOwnership(t *testing.T) {
+	store := memory.NewStore()
+	quoinSvc := service.NewQuoinServiceWithStore(ownerUser, store)
+	if _, err := quoinSvc.CreateQuoin(newQuoin("k8s")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := quoinSvc.UpdateQuoinAccess("k8s", eve.Group("acme"), eve.POLICY_READ); err != nil {
+		t.Fatalf("UpdateQuoinAccess returns error: %v", err)
+	}
+	if _, err := service.NewQuoinServiceWithStore(otherUser, store).GetQuoin("k8s"); err != nil {
+		t.Errorf("Granted group should read quoin: %v", err)
+	}
+	if err := quoinSvc.DeleteQuoinAccess("k8s", eve.Group("acme")); err != nil {
+		t.Fatalf("DeleteQuoinAccess returns error: %v", err)
+	}
+	if _, err := service.NewQuoinServiceWithStore(otherUser, store).GetQuoin("k8s"); err == nil {
+		t.Errorf("Revoked group should not read quoin")
+	}
+
+	if err := quoinSvc.TransferQuoinOwnership("k8s", otherUser.Id); err == nil {
+		t.Errorf("Owner should not transfer ownership without admin role")
+	}
+	if err := service.NewQuoinServiceWithStore(adminUser, store).TransferQuoinOwnership("k8s", otherUser.Id); err != nil {
+		t.Fatalf("TransferQuoinOwnership returns error: %v", err)
+	}
+	quoin, err := service.NewQuoinServiceWithStore(otherUser, store).GetQuoin("k8s")
+	if err != nil || quoin.Authorization.Owner != otherUser.Id {
+		t.Errorf("New owner should own quoin: %v", err)
+	}
+	if records := store.AuditRecords(); len(records) != 1 || records[0].Action != eve.AUDIT_TRANSFER_OWNERSHIP {
+		t.Errorf("Ownership transfer should be audited, got %v", records)
+	}
+}
+
+func TestQuoinService_GetQuoinArchives(t *testing.T) {
+	store := memory.NewStore()
+	quoinSvc := service.NewQuoinServiceWithStore(ownerUser, store)
+	if _, err := quoinSvc.CreateQuoin(newQuoin("k8s")); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, sha := range []string{"aaa", "bbb"} {
+		archive := &eve.QuoinArchive{
+			QuoinName:     "k8s",
+			Modules:       []byte("data"),
+			Uploader:      ownerUser.Id,
+			Sha256:        sha,
+			Authorization: newQuoin("k8s").Authorization,
+		}
+		if err := store.InsertQuoinArchive(archive); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, archive.Id)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	archives, err := quoinSvc.GetQuoinArchives("k8s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Id != ids[1] || archives[1].Id != ids[0] {
+		t.Fatalf("Archives should be listed newest first, got %#v", archives)
+	}
+	if archives[0].Modules != nil || archives[0].Sha256 != "bbb" || archives[0].Uploader != ownerUser.Id {
+		t.Errorf("Unexpected archive summary %#v", archives[0])
+	}
+	if archives, _ := quoinSvc.GetQuoinArchives("missing"); archives != nil {
+		t.Errorf("Missing quoin should have no archives, got %v", archives)
+	}
+}
+
+func TestQuoinService_DeleteAndCollectQuoinArchives(t *testing.T) {
+	store := memory.NewStore()
+	quoinSvc := service.NewQuoinServiceWithStore(ownerUser, store)
+	if _, err := quoinSvc.CreateQuoin(newQuoin("k8s")); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for i := 0; i < 3; i++ {
+		archive := &eve.QuoinArchive{QuoinName: "k8s", Authorization: newQuoin("k8s").Authorization}
+		if err := store.InsertQuoinArchive(archive); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, archive.Id)
+	}
+	pinned := newInfrastructure("dev", eve.DEPLOYED)
+	pinned.Quoin.ArchiveUri = "http://localhost:8088/quoin/k8s/upload/" + ids[0]
+	store.InsertInfrastructure(pinned)
+
+	if _, ok := quoinSvc.DeleteQuoinArchive(ids[0]).(*eve.ArchiveInUseError); !ok {
+		t.Errorf("Archive pinned by infrastructure should not be deleted")
+	}
+	if _, ok := quoinSvc.DeleteQuoinArchive(ids[2]).(*eve.ArchiveInUseError); !ok {
+		t.Errorf("Current archive of quoin should not be deleted")
+	}
+	if err := quoinSvc.DeleteQuoinArchive(ids[1]); err != nil {
+		t.Fatalf("DeleteQuoinArchive returns error: %v", err)
+	}
+	if archive, _ := store.GetQuoinArchiveById(ids[1]); archive != nil {
+		t.Errorf("Deleted archive should be removed")
+	}
+
+	quoin, _ := quoinSvc.GetQuoin("k8s")
+	quoin.Status = eve.OBSOLETED
+	store.UpdateQuoin("k8s", quoin)
+	if _, err := quoinSvc.CollectQuoinArchives(false); err == nil {
+		t.Errorf("Only admin users should collect archives")
+	}
+	adminSvc := service.NewQuoinServiceWithStore(adminUser, store)
+	archives, err := adminSvc.CollectQuoinArchives(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 || archives[0].Id != ids[2] {
+		t.Fatalf("Only the unreferenced archive should be collected, got %#v", archives)
+	}
+	if archive, _ := store.GetQuoinArchiveById(ids[2]); archive == nil {
+		t.Errorf("Dry run should not delete archives")
+	}
+	if _, err := adminSvc.CollectQuoinArchives(false); err != nil {
+		t.Fatal(err)
+	}
+	if archive, _ := store.GetQuoinArchiveById(ids[2]); archive != nil {
+		t.Errorf("Collected archive should be deleted")
+	}
+	if archive, _ := store.GetQuoinArchiveById(ids[0]); archive == nil {
+		t.Errorf("Pinned archive should be kept")
+	}
+}
+
+func TestQuoinService_FailedInfrastructureKeepsArchive(t *testing.T) {
+	store := memory.NewStore()
+	quoinSvc := service.NewQuoinServiceWithStore(ownerUser, store)
+	if _, err := quoinSvc.CreateQuoin(newQuoin("k8s")); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for i := 0; i < 3; i++ {
+		archive := &eve.QuoinArchive{QuoinName: "k8s", Authorization: newQuoin("k8s").Authorization}
+		if err := store.InsertQuoinArchive(archive); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, archive.Id)
+	}
+	failed := newInfrastructure("failed", eve.FAILED)
+	failed.Quoin.ArchiveUri = "http://localhost:8088/quoin/k8s/upload/" + ids[0]
+	store.InsertInfrastructure(failed)
+	destroyed := newInfrastructure("destroyed", eve.DESTROYED)
+	destroyed.Quoin.ArchiveUri = "http://localhost:8088/quoin/k8s/upload/" + ids[1]
+	store.InsertInfrastructure(destroyed)
+
+	if _, ok := quoinSvc.DeleteQuoinArchive(ids[0]).(*eve.ArchiveInUseError); !ok {
+		t.Errorf("Archive pinned by failed infrastructure should not be deleted")
+	}
+
+	quoin, _ := quoinSvc.GetQuoin("k8s")
+	quoin.Status = eve.OBSOLETED
+	store.UpdateQuoin("k8s", quoin)
+	archives, err := service.NewQuoinServiceWithStore(adminUser, store).CollectQuoinArchives(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 {
+		t.Errorf("Archives of destroyed infrastructure and quoin's current archive should be collected, got %#v", archives)
+	}
+	if archive, _ := store.GetQuoinArchiveById(ids[0]); archive == nil {
+		t.Errorf("Archive pinned by failed infrastructure should be kept")
+	}
+}
@@ -0,0 +1,51 @@
+package rethinkdb
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	r "gopkg.in/gorethink/gorethink.v3"
+)
+
+const (
+	AUDIT_TABLE = "audit"
+)
+
+func (db *DbSession) InsertAuditRecord(record *eve.AuditRecord) error {
+	res, err := r.DB(db.DbName).Table(AUDIT_TABLE).Insert(
+		map[string]interface{}{
+			"ResourceType": record.ResourceType,
+			"ResourceName": record.ResourceName,
+			"Action":       record.Action,
+			"Actor":        record.Actor,
+			"Metadata":     record.Metadata,
+			"Timestamp":    record.Timestamp,
+		}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	if res.Inserted == 1 {
+		record.Id = res.GeneratedKeys[0]
+	}
+	log.Printf("%d row inserted. \n", res.Inserted)
+	return nil
+}
+
+// transferOwnership returns the function replacing resource's owner and moving the owner's group access
+func transferOwnership(from eve.UserId, to eve.UserId) func(resource r.Term) r.Term {
+	return func(resource r.Term) r.Term {
+		return resource.Without(map[string]interface{}{
+			"Authorization": map[string]interface{}{
+				"GroupAccess": map[string]interface{}{
+					string(from): true,
+				},
+			},
+		}).Merge(map[string]interface{}{
+			"Authorization": map[string]interface{}{
+				"Owner": to,
+				"GroupAccess": map[string]interface{}{
+					string(to): eve.POLICY_ALL,
+				},
+			},
+		})
+	}
+}
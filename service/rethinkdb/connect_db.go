@@ -2,6 +2,7 @@ package rethinkdb
 
 import (
 	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
 	"github.com/concur/eve/pkg/config"
 	r "gopkg.in/gorethink/gorethink.v3"
 	"sync"
@@ -14,6 +15,8 @@ type DbSession struct {
 
 var defaultSession *DbSession
 
+var _ eve.Store = (*DbSession)(nil)
+
 func buildSession() error {
 	var mu sync.Mutex
 	dbConfig := config.NewRethinkDbConfig()
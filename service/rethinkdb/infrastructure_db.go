@@ -1,7 +1,7 @@
 package rethinkdb
 
 import (
-	"time"
+	"fmt"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
@@ -10,6 +10,11 @@ import (
 
 const (
 	INFRA_TABLE = "infrastructure"
+
+	QUOIN_NAME_INDEX = "QuoinName" // infrastructure's Quoin.Name
+	STATUS_INDEX     = "Status"
+	OWNER_INDEX      = "Owner"  // Authorization.Owner
+	LABEL_INDEX      = "Labels" // multi index of "key=value" label pairs
 )
 
 func (db *DbSession) InsertInfrastructure(infra *eve.Infrastructure) error {
@@ -23,14 +28,18 @@ func (db *DbSession) InsertInfrastructure(infra *eve.Infrastructure) error {
 				"Variables":  infra.Quoin.Variables,
 			},
 			"ProviderSlug": infra.ProviderSlug,
+			"Organization": infra.Organization,
+			"Role":         infra.Role,
 			"Status":       infra.Status,
 			"Error":        infra.Error,
 			"Variables":    infra.Variables,
+			"Labels":       infra.Labels,
 			"Authorization": map[string]interface{}{
 				"Owner":       infra.Authorization.Owner,
 				"GroupAccess": infra.Authorization.GroupAccess,
 			},
-			"Timestamp": r.EpochTime(time.Now().Unix()),
+			"CreatedAt": r.Now(),
+			"UpdatedAt": r.Now(),
 		},
 	).RunWrite(db.Session)
 	if err != nil {
@@ -40,21 +49,91 @@ func (db *DbSession) InsertInfrastructure(infra *eve.Infrastructure) error {
 	return nil
 }
 
-func (db *DbSession) UpdateInfrastructureState(name string, state map[string]interface{}) error {
-	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(
-		map[string]interface{}{
-			"State": state,
-		}).RunWrite(db.Session)
+// UpdateInfrastructureState replaces the stored state instead of merging into it, so no field of
+// a plaintext state is left beside its encrypted replacement
+func (db *DbSession) UpdateInfrastructureState(name string, state map[string]interface{}, condition *eve.StateCondition) error {
+	_, err := db.updateInfrastructureState(name, state, condition)
+	return err
+}
+
+// updateInfrastructureState replaces the stored state only when condition accepts it. The condition is evaluated
+// in the single document update like transitInfrastructure, so concurrent writes cannot both pass it.
+// It returns false when the infrastructure doesn't exist
+func (db *DbSession) updateInfrastructureState(name string, state map[string]interface{}, condition *eve.StateCondition) (bool, error) {
+	var stored interface{}
+	if state != nil {
+		stored = r.Literal(state)
+	}
+	fields := map[string]interface{}{
+		"State":     stored,
+		"UpdatedAt": r.Now(),
+	}
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(func(infra r.Term) r.Term {
+		return r.Branch(stateAccepted(infra, state, condition), fields, map[string]interface{}{})
+	}).RunWrite(db.Session)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if res.Skipped > 0 {
+		return false, nil
+	}
+	if res.Replaced == 0 {
+		infra, err := db.GetInfrastructureByName(name)
+		if err != nil {
+			return true, err
+		}
+		lock, err := db.GetStateLock(name)
+		if err != nil {
+			return true, err
+		}
+		if infra != nil {
+			if err := condition.Check(name, lock, infra.State, state); err != nil {
+				return true, err
+			}
+		}
+		return true, fmt.Errorf("State of infrastructure %s was changed while it was written", name)
 	}
 	log.Printf("%d row replaced. \n", res.Replaced)
-	return nil
+	return true, nil
+}
+
+// stateAccepted evaluates condition against infrastructure document like eve.StateCondition's Check
+func stateAccepted(infra r.Term, state map[string]interface{}, condition *eve.StateCondition) r.Term {
+	if condition == nil {
+		return r.Expr(true)
+	}
+	accepted := infra.HasFields(STATE_LOCK_FIELD).Not().Or(infra.Field(STATE_LOCK_FIELD).Field("ID").Eq(condition.LockId))
+	if condition.RejectStale {
+		serial, lineage := eve.StateSerial(state)
+		stored := infra.Field("State").Default(nil)
+		accepted = accepted.And(r.Branch(stored.Eq(nil).Or(stored.Keys().IsEmpty()), true,
+			stored.Field("serial").Default(0).Le(serial).And(
+				stored.Field("lineage").Default("").Do(func(storedLineage r.Term) r.Term {
+					return storedLineage.Eq("").Or(storedLineage.Eq(lineage))
+				}))))
+	}
+	return accepted
 }
 
+// UpdateInfrastructureStatus moves infrastructure to status atomically, rejecting transitions not in eve.InfrastructureTransitions
 func (db *DbSession) UpdateInfrastructureStatus(name string, status eve.Status) error {
-	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(map[string]interface{}{
+	return db.transitInfrastructure(name, status, map[string]interface{}{
 		"Status": status,
+	})
+}
+
+// UpdateInfrastructureError moves infrastructure to FAILED status with the error, or clears infrastructure's error when it's nil
+func (db *DbSession) UpdateInfrastructureError(name string, infraError error) error {
+	if infraError != nil {
+		return db.transitInfrastructure(name, eve.FAILED, map[string]interface{}{
+			"Status": eve.FAILED,
+			"Error":  infraError.Error(),
+		})
+	}
+
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(map[string]interface{}{
+		"Error":     "",
+		"UpdatedAt": r.Now(),
 	}).RunWrite(db.Session)
 	if err != nil {
 		return err
@@ -63,20 +142,77 @@ func (db *DbSession) UpdateInfrastructureStatus(name string, status eve.Status)
 	return nil
 }
 
-func (db *DbSession) UpdateInfrastructureError(name string, infraError error) error {
-	var res r.WriteResponse
-	var err error
+// transitInfrastructure updates fields only when infrastructure's current status can move to status to.
+// The condition is evaluated in the single document update, so concurrent transitions cannot both succeed.
+// UpdatedAt, and LastAppliedAt or LastDestroyedAt for DEPLOYED or DESTROYED, are set in the same update.
+func (db *DbSession) transitInfrastructure(name string, to eve.Status, fields map[string]interface{}) error {
+	fields["UpdatedAt"] = r.Now()
+	switch to {
+	case eve.DEPLOYED:
+		fields["LastAppliedAt"] = r.Now()
+	case eve.DESTROYED:
+		fields["LastDestroyedAt"] = r.Now()
+	}
 
-	if infraError != nil {
-		res, err = r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(map[string]interface{}{
-			"Status": eve.FAILED,
-			"Error":  infraError.Error(),
-		}).RunWrite(db.Session)
-	} else {
-		res, err = r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(map[string]interface{}{
-			"Error": "",
-		}).RunWrite(db.Session)
+	var sources []int
+	for _, from := range eve.TransitionSources(to) {
+		sources = append(sources, int(from))
 	}
+
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(func(infra r.Term) r.Term {
+		return r.Branch(r.Expr(sources).Contains(infra.Field("Status")), fields, map[string]interface{}{})
+	}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	if res.Skipped > 0 {
+		return fmt.Errorf("Infrastructure %s doesn't exist", name)
+	}
+	if res.Replaced == 0 {
+		transitionErr := &eve.TransitionError{Name: name, To: to}
+		if infra, err := db.GetInfrastructureByName(name); err == nil && infra != nil {
+			transitionErr.From = infra.Status
+		}
+		return transitionErr
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+func (db *DbSession) UpdateInfrastructureAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(map[string]interface{}{
+		"Authorization": map[string]interface{}{
+			"GroupAccess": map[string]interface{}{
+				string(group): mode,
+			},
+		},
+	}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+func (db *DbSession) DeleteInfrastructureAccess(name string, group eve.Group) error {
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Replace(func(infra r.Term) r.Term {
+		return infra.Without(map[string]interface{}{
+			"Authorization": map[string]interface{}{
+				"GroupAccess": map[string]interface{}{
+					string(group): true,
+				},
+			},
+		})
+	}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+func (db *DbSession) UpdateInfrastructureOwner(name string, from eve.UserId, to eve.UserId) error {
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Replace(transferOwnership(from, to)).RunWrite(db.Session)
 	if err != nil {
 		return err
 	}
@@ -100,11 +236,12 @@ func (db *DbSession) GetInfrastructureByName(name string) (*eve.Infrastructure,
 	return &infrastructure, nil
 }
 
+// GetInfrastructuresByQuoin returns infrastructures using exactly the quoin which aren't destroyed, including failed ones
 func (db *DbSession) GetInfrastructuresByQuoin(name string) ([]eve.Infrastructure, error) {
 	var infrastructures []eve.Infrastructure
-	cursor, err := r.DB(db.DbName).Table(INFRA_TABLE).Filter(func(infra r.Term) r.Term {
-		return infra.Field("Status").Lt(int(eve.DESTROYED)).And(infra.Field("Quoin").Field("Name").Match(name))
-	}).Run(db.Session)
+	cursor, err := r.DB(db.DbName).Table(INFRA_TABLE).GetAllByIndex(QUOIN_NAME_INDEX, name).Filter(func(infra r.Term) r.Term {
+		return infra.Field("Status").Ne(int(eve.DESTROYED))
+	}).Without("State").OrderBy("Name").Run(db.Session)
 	defer cursor.Close()
 	if err != nil {
 		return nil, err
@@ -117,3 +254,14 @@ func (db *DbSession) GetInfrastructuresByQuoin(name string) ([]eve.Infrastructur
 	}
 	return infrastructures, nil
 }
+
+// activeStatuses returns the statuses of not yet destroyed infrastructures as Status index keys
+func activeStatuses() []interface{} {
+	var statuses []interface{}
+	for _, status := range eve.Statuses() {
+		if status < eve.DESTROYED {
+			statuses = append(statuses, int(status))
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,110 @@
+package rethinkdb
+
+import (
+	"github.com/concur/eve"
+	r "gopkg.in/gorethink/gorethink.v3"
+)
+
+func (db *DbSession) ListQuoins(options eve.ListOptions) ([]eve.Quoin, error) {
+	var quoins []eve.Quoin
+	cursor, err := db.listQuery(QUOIN_TABLE, options).Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(&quoins); err != nil {
+		return nil, err
+	}
+	return quoins, nil
+}
+
+// ListInfrastructures returns infrastructures matching options without their states
+func (db *DbSession) ListInfrastructures(options eve.ListOptions) ([]eve.Infrastructure, error) {
+	var infrastructures []eve.Infrastructure
+	cursor, err := db.listQuery(INFRA_TABLE, options).Without("State").Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(&infrastructures); err != nil {
+		return nil, err
+	}
+	return infrastructures, nil
+}
+
+// listQuery selects table's documents matching options ordered by name. The owner, quoin or status
+// filter is looked up on its secondary index, and the remaining filters are applied to the lookup result.
+func (db *DbSession) listQuery(table string, options eve.ListOptions) r.Term {
+	query := r.DB(db.DbName).Table(table)
+	var filters []func(doc r.Term) r.Term
+	owner, quoin, status := options.Owner != "", options.Quoin != "" && table == INFRA_TABLE, options.Status != nil
+	selector := options.Selector
+	switch {
+	case owner:
+		query, owner = query.GetAllByIndex(OWNER_INDEX, string(options.Owner)), false
+	case quoin:
+		query, quoin = query.GetAllByIndex(QUOIN_NAME_INDEX, options.Quoin), false
+	case status && table == INFRA_TABLE:
+		query, status = query.GetAllByIndex(STATUS_INDEX, int(*options.Status)), false
+	default:
+		// A document has one value per label key, so an equality or set requirement doesn't return duplicates
+		for i, req := range selector {
+			if req.Operator == eve.SELECTOR_EQUALS || req.Operator == eve.SELECTOR_IN {
+				var pairs []interface{}
+				for _, value := range req.Values {
+					pairs = append(pairs, req.Key+"="+value)
+				}
+				query = query.GetAllByIndex(LABEL_INDEX, pairs...)
+				selector = append(append(eve.Selector{}, selector[:i]...), selector[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if owner {
+		filters = append(filters, func(doc r.Term) r.Term {
+			return doc.Field("Authorization").Field("Owner").Eq(string(options.Owner))
+		})
+	}
+	if quoin {
+		filters = append(filters, func(doc r.Term) r.Term { return doc.Field("Quoin").Field("Name").Eq(options.Quoin) })
+	}
+	if status {
+		filters = append(filters, func(doc r.Term) r.Term { return doc.Field("Status").Eq(int(*options.Status)) })
+	}
+	if options.ProviderSlug != "" && table == INFRA_TABLE {
+		filters = append(filters, func(doc r.Term) r.Term { return doc.Field("ProviderSlug").Eq(options.ProviderSlug) })
+	}
+	for _, req := range selector {
+		filters = append(filters, labelFilter(req))
+	}
+	if options.After != "" {
+		filters = append(filters, func(doc r.Term) r.Term { return doc.Field("Name").Gt(options.After) })
+	}
+	for _, filter := range filters {
+		query = query.Filter(filter)
+	}
+
+	query = query.OrderBy("Name")
+	if options.Limit > 0 {
+		query = query.Limit(options.Limit)
+	}
+	return query
+}
+
+// labelFilter translates a label selector requirement into a document filter
+func labelFilter(req eve.Requirement) func(doc r.Term) r.Term {
+	return func(doc r.Term) r.Term {
+		hasLabel := doc.HasFields(map[string]interface{}{"Labels": req.Key})
+		value := doc.Field("Labels").Field(req.Key)
+		switch req.Operator {
+		case eve.SELECTOR_EQUALS, eve.SELECTOR_IN:
+			return r.Branch(hasLabel, r.Expr(req.Values).Contains(value), false)
+		case eve.SELECTOR_NOT_EQUALS, eve.SELECTOR_NOT_IN:
+			return r.Branch(hasLabel, r.Expr(req.Values).Contains(value).Not(), true)
+		case eve.SELECTOR_DOES_NOT_EXIST:
+			return hasLabel.Not()
+		}
+		return hasLabel
+	}
+}
@@ -0,0 +1,397 @@
+package rethinkdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	r "gopkg.in/gorethink/gorethink.v3"
+)
+
+const MIGRATION_TABLE = "migrations"
+
+// Migration is one versioned schema change. Up must be safe to re-run after a partial failure,
+// because a migration is recorded only once Up succeeds.
+type Migration struct {
+	Version     int
+	Description string
+	Tables      []string // tables which exist once the migration is applied
+	Up          func(db *DbSession) error
+}
+
+// MigrationRecord is an applied migration stored in the migrations table
+type MigrationRecord struct {
+	Version     int
+	Description string
+	AppliedAt   time.Time
+}
+
+// MigrationState is a migration with its applied record, which is nil while it's pending
+type MigrationState struct {
+	Migration
+	Applied *MigrationRecord
+}
+
+var coreTables = []string{PROVIDER_TABLE, QUOIN_TABLE, QUOIN_ARCHIVE_TABLE, INFRA_TABLE, AUDIT_TABLE}
+
+// Migrations are applied by ascending version. Append new migrations, never edit applied ones.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Create provider, quoin, quoinArchive, infrastructure and audit tables",
+		Tables:      coreTables,
+		Up: func(db *DbSession) error {
+			for _, table := range coreTables {
+				if err := db.createTable(table, "Id"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "Create QuoinName, Status and Owner secondary indexes",
+		Up: func(db *DbSession) error {
+			for _, index := range []struct {
+				table string
+				name  string
+				field func(doc r.Term) interface{}
+			}{
+				{INFRA_TABLE, QUOIN_NAME_INDEX, func(infra r.Term) interface{} { return infra.Field("Quoin").Field("Name") }},
+				{INFRA_TABLE, STATUS_INDEX, func(infra r.Term) interface{} { return infra.Field("Status") }},
+				{INFRA_TABLE, OWNER_INDEX, ownerIndex},
+				{QUOIN_TABLE, OWNER_INDEX, ownerIndex},
+			} {
+				if err := db.createIndex(index.table, index.name, index.field); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "Create Labels multi indexes",
+		Up: func(db *DbSession) error {
+			for _, table := range []string{QUOIN_TABLE, INFRA_TABLE} {
+				if err := db.createIndex(table, LABEL_INDEX, labelIndex, r.IndexCreateOpts{Multi: true}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "Backfill CreatedAt and UpdatedAt from legacy Timestamp",
+		Up: func(db *DbSession) error {
+			for _, table := range []string{PROVIDER_TABLE, QUOIN_TABLE, INFRA_TABLE} {
+				if _, err := r.DB(db.DbName).Table(table).Filter(func(doc r.Term) r.Term {
+					return doc.HasFields("CreatedAt").Not()
+				}).Update(func(doc r.Term) interface{} {
+					createdAt := doc.Field("Timestamp").Default(r.Now())
+					return map[string]interface{}{"CreatedAt": createdAt, "UpdatedAt": createdAt}
+				}).RunWrite(db.Session); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     5,
+		Description: "Create quoinArchive QuoinName index and backfill archive size, checksum, uploader and upload time",
+		Up: func(db *DbSession) error {
+			if err := db.createIndex(QUOIN_ARCHIVE_TABLE, QUOIN_NAME_INDEX, func(archive r.Term) interface{} {
+				return archive.Field("QuoinName")
+			}); err != nil {
+				return err
+			}
+			return db.backfillQuoinArchives()
+		},
+	},
+	{
+		Version:     6,
+		Description: "Create stateVersion table and record infrastructures' current states as their first versions",
+		Tables:      []string{STATE_VERSION_TABLE},
+		Up: func(db *DbSession) error {
+			if err := db.createTable(STATE_VERSION_TABLE, "Id"); err != nil {
+				return err
+			}
+			if err := db.createIndex(STATE_VERSION_TABLE, INFRA_NAME_INDEX, func(version r.Term) interface{} {
+				return version.Field("InfrastructureName")
+			}); err != nil {
+				return err
+			}
+			return db.backfillStateVersions()
+		},
+	},
+	{
+		Version:     7,
+		Description: "Create stateLock table",
+		Tables:      []string{STATE_LOCK_TABLE},
+		Up: func(db *DbSession) error {
+			return db.createTable(STATE_LOCK_TABLE, "InfrastructureName")
+		},
+	},
+	{
+		Version:     8,
+		Description: "Convert legacy combined policy modes in GroupAccess to bit flags",
+		Up: func(db *DbSession) error {
+			for _, table := range []string{PROVIDER_TABLE, QUOIN_TABLE, QUOIN_ARCHIVE_TABLE, INFRA_TABLE} {
+				if _, err := r.DB(db.DbName).Table(table).Filter(func(doc r.Term) r.Term {
+					return doc.Field("Authorization").Field("GroupAccess").Default(nil).Ne(nil)
+				}).Update(func(doc r.Term) interface{} {
+					groupAccess := doc.Field("Authorization").Field("GroupAccess").CoerceTo("array").Map(func(access r.Term) interface{} {
+						return []interface{}{access.Nth(0), legacyPolicyMode(access.Nth(1))}
+					}).CoerceTo("object")
+					return map[string]interface{}{"Authorization": map[string]interface{}{"GroupAccess": r.Literal(groupAccess)}}
+				}).RunWrite(db.Session); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     9,
+		Description: "Move state locks from stateLock table into infrastructure documents",
+		Up: func(db *DbSession) error {
+			return db.moveStateLocks()
+		},
+	},
+}
+
+// legacyPolicyMode converts a legacy combined mode to its bit flags, and keeps other modes as they are
+func legacyPolicyMode(mode r.Term) r.Term {
+	converted := mode
+	for legacy, flags := range eve.LegacyPolicyModes {
+		converted = r.Branch(mode.Eq(int(legacy)), int(flags), converted)
+	}
+	return converted
+}
+
+// backfillQuoinArchives sets Size, Sha256, Uploader and CreatedAt of archives uploaded before they were recorded.
+// Checksums are computed here because rethinkdb has no SHA-256 function.
+func (db *DbSession) backfillQuoinArchives() error {
+	cursor, err := r.DB(db.DbName).Table(QUOIN_ARCHIVE_TABLE).Filter(func(archive r.Term) r.Term {
+		return archive.HasFields("Sha256").Not()
+	}).Run(db.Session)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+	var archive struct {
+		eve.QuoinArchive
+		Timestamp time.Time
+	}
+	for cursor.Next(&archive) {
+		createdAt := archive.Timestamp
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		sum := sha256.Sum256(archive.Modules)
+		if _, err := r.DB(db.DbName).Table(QUOIN_ARCHIVE_TABLE).Get(archive.Id).Update(map[string]interface{}{
+			"Uploader":  archive.Authorization.Owner,
+			"Size":      len(archive.Modules),
+			"Sha256":    hex.EncodeToString(sum[:]),
+			"CreatedAt": createdAt,
+		}).RunWrite(db.Session); err != nil {
+			return err
+		}
+		archive.QuoinArchive, archive.Timestamp = eve.QuoinArchive{}, time.Time{}
+	}
+	return cursor.Err()
+}
+
+// backfillStateVersions records the current state of infrastructures without any version.
+// The writer of those states is unknown and left empty.
+func (db *DbSession) backfillStateVersions() error {
+	cursor, err := r.DB(db.DbName).Table(INFRA_TABLE).Filter(func(infra r.Term) r.Term {
+		return infra.Field("State").Default(nil).Ne(nil)
+	}).Run(db.Session)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+	var infra eve.Infrastructure
+	for cursor.Next(&infra) {
+		versions, err := db.GetStateVersionsByInfrastructure(infra.Name)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 && len(infra.State) > 0 {
+			serial, lineage := eve.StateSerial(infra.State)
+			if _, err := r.DB(db.DbName).Table(STATE_VERSION_TABLE).Insert(map[string]interface{}{
+				"InfrastructureName": infra.Name,
+				"Serial":             serial,
+				"Lineage":            lineage,
+				"Writer":             "",
+				"State":              infra.State,
+				"CreatedAt":          infra.UpdatedAt,
+			}).RunWrite(db.Session); err != nil {
+				return err
+			}
+		}
+		infra = eve.Infrastructure{}
+	}
+	return cursor.Err()
+}
+
+// moveStateLocks stores each lock of stateLock table in its infrastructure's document, so state writes check the lock
+// in the same update as the state. Locks of deleted infrastructures are dropped. The emptied table is kept,
+// because migration 7 defines it
+func (db *DbSession) moveStateLocks() error {
+	cursor, err := r.DB(db.DbName).Table(STATE_LOCK_TABLE).Run(db.Session)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+	var lock map[string]interface{}
+	for cursor.Next(&lock) {
+		name, _ := lock["InfrastructureName"].(string)
+		if _, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Update(func(infra r.Term) r.Term {
+			return r.Branch(infra.HasFields(STATE_LOCK_FIELD), map[string]interface{}{}, map[string]interface{}{
+				STATE_LOCK_FIELD: lock,
+			})
+		}).RunWrite(db.Session); err != nil {
+			return err
+		}
+		if _, err := r.DB(db.DbName).Table(STATE_LOCK_TABLE).Get(name).Delete().RunWrite(db.Session); err != nil {
+			return err
+		}
+		lock = nil
+	}
+	return cursor.Err()
+}
+
+// labelIndex indexes document by each of its labels as "key=value"
+func labelIndex(doc r.Term) interface{} {
+	return r.Branch(doc.HasFields("Labels"), doc.Field("Labels").CoerceTo("array").Map(func(label r.Term) interface{} {
+		return label.Nth(0).Add("=", label.Nth(1))
+	}), []interface{}{})
+}
+
+func ownerIndex(doc r.Term) interface{} {
+	return doc.Field("Authorization").Field("Owner")
+}
+
+// Tables returns every table the migrations define, including the migrations table itself
+func Tables() []string {
+	tables := []string{MIGRATION_TABLE}
+	for _, migration := range Migrations {
+		tables = append(tables, migration.Tables...)
+	}
+	return tables
+}
+
+// Migrate applies pending migrations in order, and returns the applied ones
+func (db *DbSession) Migrate() ([]Migration, error) {
+	if err := db.dbInit(); err != nil {
+		return nil, err
+	}
+	if err := db.createTable(MIGRATION_TABLE, "Version"); err != nil {
+		return nil, err
+	}
+	states, err := db.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, state := range states {
+		if state.Applied != nil {
+			continue
+		}
+		log.Printf("Applying migration %d: %s", state.Version, state.Description)
+		if err := state.Up(db); err != nil {
+			return applied, fmt.Errorf("Migration %d failed: %v", state.Version, err)
+		}
+		record := MigrationRecord{Version: state.Version, Description: state.Description, AppliedAt: time.Now().UTC()}
+		if _, err := r.DB(db.DbName).Table(MIGRATION_TABLE).Insert(record).RunWrite(db.Session); err != nil {
+			return applied, fmt.Errorf("Migration %d is applied but failed to be recorded: %v", state.Version, err)
+		}
+		applied = append(applied, state.Migration)
+	}
+	return applied, nil
+}
+
+// MigrationStatus returns every migration by ascending version with its applied record
+func (db *DbSession) MigrationStatus() ([]MigrationState, error) {
+	records := make(map[int]*MigrationRecord)
+	exists, err := db.tableExists(MIGRATION_TABLE)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		cursor, err := r.DB(db.DbName).Table(MIGRATION_TABLE).Run(db.Session)
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close()
+		var record MigrationRecord
+		for cursor.Next(&record) {
+			applied := record
+			records[applied.Version] = &applied
+		}
+		if err := cursor.Err(); err != nil {
+			return nil, err
+		}
+	}
+	states := make([]MigrationState, 0, len(Migrations))
+	for _, migration := range Migrations {
+		states = append(states, MigrationState{Migration: migration, Applied: records[migration.Version]})
+	}
+	return states, nil
+}
+
+func (db *DbSession) tableExists(name string) (bool, error) {
+	cursor, err := r.DB(db.DbName).TableList().Contains(name).Run(db.Session)
+	if err != nil {
+		return false, err
+	}
+	defer cursor.Close()
+	var exists bool
+	if err := cursor.One(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// createTable creates table with primaryKey unless it exists
+func (db *DbSession) createTable(name string, primaryKey string) error {
+	exists, err := db.tableExists(name)
+	if err != nil || exists {
+		return err
+	}
+	log.Println("Creating table:", name)
+	if _, err := r.DB(db.DbName).TableCreate(name, r.TableCreateOpts{PrimaryKey: primaryKey}).RunWrite(db.Session); err != nil {
+		return err
+	}
+	log.Println("Table", name, "is created!")
+	return nil
+}
+
+// createIndex creates secondary index on table unless it exists, and waits until it's ready
+func (db *DbSession) createIndex(table string, name string, field func(doc r.Term) interface{}, opts ...r.IndexCreateOpts) error {
+	cursor, err := r.DB(db.DbName).Table(table).IndexList().Contains(name).Run(db.Session)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+	var exists bool
+	if err := cursor.One(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		log.Println("Creating index:", table+"."+name)
+		if _, err := r.DB(db.DbName).Table(table).IndexCreateFunc(name, field, opts...).RunWrite(db.Session); err != nil {
+			return err
+		}
+	}
+	_, err = r.DB(db.DbName).Table(table).IndexWait(name).Run(db.Session)
+	return err
+}
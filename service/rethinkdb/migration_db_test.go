@@ -0,0 +1,42 @@
+package rethinkdb
+
+import (
+	"testing"
+
+	"github.com/concur/eve"
+)
+
+func TestMigrations_ordered(t *testing.T) {
+	for i, migration := range Migrations {
+		if migration.Version != i+1 {
+			t.Errorf("Migration %d should have version %d, got %d", i, i+1, migration.Version)
+		}
+		if migration.Up == nil || migration.Description == "" {
+			t.Errorf("Migration %d should have Up and Description", migration.Version)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	tables := make(map[string]bool)
+	for _, table := range Tables() {
+		tables[table] = true
+	}
+	for _, table := range []string{MIGRATION_TABLE, PROVIDER_TABLE, QUOIN_TABLE, QUOIN_ARCHIVE_TABLE, INFRA_TABLE, AUDIT_TABLE, STATE_VERSION_TABLE, STATE_LOCK_TABLE} {
+		if !tables[table] {
+			t.Errorf("Tables should include %s", table)
+		}
+	}
+}
+
+func TestActiveStatuses(t *testing.T) {
+	statuses := activeStatuses()
+	for _, status := range statuses {
+		if status.(int) >= int(eve.DESTROYED) {
+			t.Errorf("activeStatuses should not include destroyed or later statuses, got %v", statuses)
+		}
+	}
+	if len(statuses) != 4 {
+		t.Errorf("activeStatuses should include default, validated, running and deployed, got %v", statuses)
+	}
+}
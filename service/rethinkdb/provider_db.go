@@ -1,6 +1,10 @@
 package rethinkdb
 
 import (
+	"fmt"
+	"regexp"
+
+	log "github.com/Sirupsen/logrus"
 	"github.com/concur/eve"
 	r "gopkg.in/gorethink/gorethink.v3"
 )
@@ -9,6 +13,92 @@ const (
 	PROVIDER_TABLE = "provider"
 )
 
+func (db *DbSession) InsertProvider(provider *eve.Provider) error {
+	res, err := r.DB(db.DbName).Table(PROVIDER_TABLE).Insert(
+		map[string]interface{}{
+			"Id":   r.UUID(provider.Name),
+			"Name": provider.Name,
+			"Schema": map[string]interface{}{
+				"Type": provider.Schema.Type,
+				"Data": provider.Schema.Data,
+			},
+			"Authorization": map[string]interface{}{
+				"Owner":       provider.Authorization.Owner,
+				"GroupAccess": provider.Authorization.GroupAccess,
+			},
+			"CreatedAt": r.Now(),
+			"UpdatedAt": r.Now(),
+		}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row inserted. \n", res.Inserted)
+	if res.Inserted == 1 {
+		provider.Id = res.GeneratedKeys[0]
+	}
+	return nil
+}
+
+// UpdateProvider replaces provider's schema. Provider's authorization is kept as it is
+func (db *DbSession) UpdateProvider(name string, provider *eve.Provider) error {
+	res, err := r.DB(db.DbName).Table(PROVIDER_TABLE).Get(r.UUID(name)).Update(
+		map[string]interface{}{
+			"Schema": r.Literal(map[string]interface{}{
+				"Type": provider.Schema.Type,
+				"Data": provider.Schema.Data,
+			}),
+			"UpdatedAt": r.Now(),
+		}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+func (db *DbSession) UpdateProviderAccess(name string, group eve.Group, mode eve.PolicyMode) error {
+	res, err := r.DB(db.DbName).Table(PROVIDER_TABLE).Get(r.UUID(name)).Update(
+		map[string]interface{}{
+			"Authorization": map[string]interface{}{
+				"GroupAccess": map[string]interface{}{
+					string(group): mode,
+				},
+			},
+			"UpdatedAt": r.Now(),
+		}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+func (db *DbSession) DeleteProviderAccess(name string, group eve.Group) error {
+	res, err := r.DB(db.DbName).Table(PROVIDER_TABLE).Get(r.UUID(name)).Replace(func(provider r.Term) r.Term {
+		return provider.Without(map[string]interface{}{
+			"Authorization": map[string]interface{}{
+				"GroupAccess": map[string]interface{}{
+					string(group): true,
+				},
+			},
+		})
+	}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+func (db *DbSession) DeleteProvider(name string) error {
+	res, err := r.DB(db.DbName).Table(PROVIDER_TABLE).Get(r.UUID(name)).Delete().RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row deleted. \n", res.Deleted)
+	return nil
+}
+
 func (db *DbSession) GetProviderByName(name string) (*eve.Provider, error) {
 	var provider eve.Provider
 	cursor, err := r.DB(db.DbName).Table(PROVIDER_TABLE).Get(r.UUID(name)).Run(db.Session)
@@ -24,3 +114,36 @@ func (db *DbSession) GetProviderByName(name string) (*eve.Provider, error) {
 	}
 	return &provider, nil
 }
+
+func (db *DbSession) GetProviders() ([]eve.Provider, error) {
+	var providers []eve.Provider
+	cursor, err := r.DB(db.DbName).Table(PROVIDER_TABLE).OrderBy("Name").Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return nil, err
+	}
+	if cursor.IsNil() {
+		return nil, nil
+	}
+	if err = cursor.All(&providers); err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+// CountInfrastructuresByProvider counts not yet destroyed infrastructures using the provider
+func (db *DbSession) CountInfrastructuresByProvider(name string) (int, error) {
+	var count int
+	slugPrefix := fmt.Sprintf("^%s:", regexp.QuoteMeta(name))
+	cursor, err := r.DB(db.DbName).Table(INFRA_TABLE).GetAllByIndex(STATUS_INDEX, activeStatuses()...).Filter(func(infra r.Term) r.Term {
+		return infra.Field("ProviderSlug").Match(slugPrefix)
+	}).Count().Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return 0, err
+	}
+	if err = cursor.One(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/concur/eve"
 	r "gopkg.in/gorethink/gorethink.v3"
 	"strings"
-	"time"
 )
 
 const (
@@ -20,11 +19,13 @@ func (db *DbSession) InsertQuoin(quoin *eve.Quoin) error {
 			"Name":       quoin.Name,
 			"ArchiveUri": quoin.ArchiveUri,
 			"Variables":  quoin.Variables,
+			"Labels":     quoin.Labels,
 			"Authorization": map[string]interface{}{
 				"Owner":       quoin.Authorization.Owner,
 				"GroupAccess": quoin.Authorization.GroupAccess,
 			},
-			"Timestamp": r.EpochTime(time.Now().Unix()),
+			"CreatedAt": r.Now(),
+			"UpdatedAt": r.Now(),
 		}).RunWrite(db.Session)
 	if err != nil {
 		return err
@@ -38,7 +39,23 @@ func (db *DbSession) InsertQuoin(quoin *eve.Quoin) error {
 	return nil
 }
 
-func (db *DbSession) UpdateQuoin(quoinName string, value interface{}) error {
+// UpdateQuoin replaces the quoin, keeping its id and creation time
+func (db *DbSession) UpdateQuoin(quoinName string, quoin *eve.Quoin) error {
+	res, err := r.DB(db.DbName).Table(QUOIN_TABLE).Get(r.UUID(quoinName)).Replace(func(stored r.Term) interface{} {
+		return r.Branch(stored.Eq(nil), nil, r.Expr(quoin).Merge(map[string]interface{}{
+			"Id":        stored.Field("Id"),
+			"CreatedAt": stored.Field("CreatedAt").Default(r.Now()),
+			"UpdatedAt": r.Now(),
+		}))
+	}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+func (db *DbSession) updateQuoin(quoinName string, value interface{}) error {
 	res, err := r.DB(db.DbName).Table(QUOIN_TABLE).Get(r.UUID(quoinName)).Update(value).RunWrite(db.Session)
 	if err != nil {
 		return err
@@ -47,6 +64,48 @@ func (db *DbSession) UpdateQuoin(quoinName string, value interface{}) error {
 	return nil
 }
 
+func (db *DbSession) UpdateQuoinAccess(quoinName string, group eve.Group, mode eve.PolicyMode) error {
+	return db.updateQuoin(quoinName, map[string]interface{}{
+		"Authorization": map[string]interface{}{
+			"GroupAccess": map[string]interface{}{
+				string(group): mode,
+			},
+		},
+	})
+}
+
+func (db *DbSession) DeleteQuoinAccess(quoinName string, group eve.Group) error {
+	res, err := r.DB(db.DbName).Table(QUOIN_TABLE).Get(r.UUID(quoinName)).Replace(func(quoin r.Term) r.Term {
+		return quoin.Without(map[string]interface{}{
+			"Authorization": map[string]interface{}{
+				"GroupAccess": map[string]interface{}{
+					string(group): true,
+				},
+			},
+		})
+	}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+// UpdateQuoinOwner transfers ownership of the quoin and all of its archives
+func (db *DbSession) UpdateQuoinOwner(quoinName string, from eve.UserId, to eve.UserId) error {
+	res, err := r.DB(db.DbName).Table(QUOIN_TABLE).Get(r.UUID(quoinName)).Replace(transferOwnership(from, to)).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	res, err = r.DB(db.DbName).Table(QUOIN_ARCHIVE_TABLE).GetAllByIndex(QUOIN_NAME_INDEX, quoinName).Replace(transferOwnership(from, to)).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
 func (db *DbSession) GetQuoinByName(name string) (*eve.Quoin, error) {
 	var quoin eve.Quoin
 	cursor, err := r.DB(db.DbName).Table(QUOIN_TABLE).Get(r.UUID(name)).Run(db.Session)
@@ -72,7 +131,10 @@ func (db *DbSession) InsertQuoinArchive(quoinArchive *eve.QuoinArchive) error {
 				"Owner":       quoinArchive.Authorization.Owner,
 				"GroupAccess": quoinArchive.Authorization.GroupAccess,
 			},
-			"Timestamp": r.EpochTime(time.Now().Unix()),
+			"Uploader":  quoinArchive.Uploader,
+			"Size":      quoinArchive.Size,
+			"Sha256":    quoinArchive.Sha256,
+			"CreatedAt": r.Now(),
 		}).RunWrite(db.Session)
 	if err != nil {
 		return err
@@ -114,3 +176,27 @@ func (db *DbSession) GetQuoinArchiveById(id string) (*eve.QuoinArchive, error) {
 	}
 	return &quoinArchive, nil
 }
+
+// GetQuoinArchivesByQuoin returns the quoin's archives without their modules, newest first
+func (db *DbSession) GetQuoinArchivesByQuoin(quoinName string) ([]eve.QuoinArchive, error) {
+	var quoinArchives []eve.QuoinArchive
+	cursor, err := r.DB(db.DbName).Table(QUOIN_ARCHIVE_TABLE).GetAllByIndex(QUOIN_NAME_INDEX, quoinName).
+		Without("Modules").OrderBy(r.Desc("CreatedAt")).Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(&quoinArchives); err != nil {
+		return nil, err
+	}
+	return quoinArchives, nil
+}
+
+func (db *DbSession) DeleteQuoinArchive(id string) error {
+	res, err := r.DB(db.DbName).Table(QUOIN_ARCHIVE_TABLE).Get(id).Delete().RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row deleted. \n", res.Deleted)
+	return nil
+}
@@ -0,0 +1,160 @@
+package rethinkdb
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	r "gopkg.in/gorethink/gorethink.v3"
+)
+
+const (
+	STATE_VERSION_TABLE = "stateVersion"
+	STATE_LOCK_TABLE    = "stateLock" // locks before they moved into infrastructure documents
+	STATE_LOCK_FIELD    = "StateLock" // infrastructure document's lock of its state
+
+	INFRA_NAME_INDEX = "InfrastructureName" // state version's InfrastructureName
+)
+
+// InsertStateVersion stores version only after its state became the infrastructure's current state,
+// so a rejected state write leaves no version behind
+func (db *DbSession) InsertStateVersion(version *eve.StateVersion, condition *eve.StateCondition) error {
+	found, err := db.updateInfrastructureState(version.InfrastructureName, version.State, condition)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("Infrastructure %s doesn't exist", version.InfrastructureName)
+	}
+
+	res, err := r.DB(db.DbName).Table(STATE_VERSION_TABLE).Insert(
+		map[string]interface{}{
+			"InfrastructureName": version.InfrastructureName,
+			"Serial":             version.Serial,
+			"Lineage":            version.Lineage,
+			"Writer":             version.Writer,
+			"RollbackOf":         version.RollbackOf,
+			"State":              version.State,
+			"CreatedAt":          r.Now(),
+		}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	if res.Inserted == 1 {
+		version.Id = res.GeneratedKeys[0]
+	}
+	log.Printf("%d row inserted. \n", res.Inserted)
+	return nil
+}
+
+func (db *DbSession) GetStateVersionById(id string) (*eve.StateVersion, error) {
+	var version eve.StateVersion
+	cursor, err := r.DB(db.DbName).Table(STATE_VERSION_TABLE).Get(id).Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return nil, err
+	}
+	if cursor.IsNil() {
+		return nil, nil
+	}
+	if err = cursor.One(&version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// GetStateVersionsByInfrastructure returns the infrastructure's versions without their states, newest first
+func (db *DbSession) GetStateVersionsByInfrastructure(name string) ([]eve.StateVersion, error) {
+	var versions []eve.StateVersion
+	cursor, err := r.DB(db.DbName).Table(STATE_VERSION_TABLE).GetAllByIndex(INFRA_NAME_INDEX, name).
+		Without("State").OrderBy(r.Desc("CreatedAt"), r.Desc("Serial")).Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(&versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (db *DbSession) UpdateStateVersionState(id string, state map[string]interface{}) error {
+	res, err := r.DB(db.DbName).Table(STATE_VERSION_TABLE).Get(id).Update(
+		map[string]interface{}{
+			"State": r.Literal(state),
+		}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
+
+// InsertStateLock stores lock in its infrastructure's document unless the document already holds a lock, so only
+// one lock of a state can be inserted, and state writes can check the lock in the same document update
+func (db *DbSession) InsertStateLock(lock *eve.StateLock) (*eve.StateLock, error) {
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(lock.InfrastructureName)).Update(func(infra r.Term) r.Term {
+		return r.Branch(infra.HasFields(STATE_LOCK_FIELD), map[string]interface{}{}, map[string]interface{}{
+			STATE_LOCK_FIELD: map[string]interface{}{
+				"ID":                 lock.ID,
+				"Operation":          lock.Operation,
+				"Info":               lock.Info,
+				"Who":                lock.Who,
+				"Version":            lock.Version,
+				"Path":               lock.Path,
+				"InfrastructureName": lock.InfrastructureName,
+				"Holder":             lock.Holder,
+				"Created":            r.Now(),
+			},
+		})
+	}).RunWrite(db.Session)
+	if err != nil {
+		return nil, err
+	}
+	if res.Skipped > 0 {
+		return nil, fmt.Errorf("Infrastructure %s doesn't exist", lock.InfrastructureName)
+	}
+	stored, err := db.GetStateLock(lock.InfrastructureName)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	if res.Replaced == 0 {
+		// The state was already locked
+		return stored, nil
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	*lock = *stored
+	return nil, nil
+}
+
+func (db *DbSession) GetStateLock(name string) (*eve.StateLock, error) {
+	var lock eve.StateLock
+	cursor, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Field(STATE_LOCK_FIELD).Default(nil).Run(db.Session)
+	defer cursor.Close()
+	if err != nil {
+		return nil, err
+	}
+	if cursor.IsNil() {
+		return nil, nil
+	}
+	if err = cursor.One(&lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// DeleteStateLock removes the lock atomically when its ID is id, or any lock when id is empty
+func (db *DbSession) DeleteStateLock(name string, id string) error {
+	res, err := r.DB(db.DbName).Table(INFRA_TABLE).Get(r.UUID(name)).Replace(func(infra r.Term) r.Term {
+		unlock := infra.HasFields(STATE_LOCK_FIELD)
+		if id != "" {
+			unlock = unlock.And(infra.Field(STATE_LOCK_FIELD).Field("ID").Eq(id))
+		}
+		return r.Branch(infra.Eq(nil).Or(unlock.Not()), infra, infra.Without(STATE_LOCK_FIELD))
+	}).RunWrite(db.Session)
+	if err != nil {
+		return err
+	}
+	log.Printf("%d row replaced. \n", res.Replaced)
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/concur/eve"
+	"github.com/concur/eve/pkg/config"
+	"github.com/concur/eve/pkg/vault"
+)
+
+// DEFAULT_KEY_ORGANIZATION names the key of infrastructures created before they recorded their organization
+const DEFAULT_KEY_ORGANIZATION = "default"
+
+// StateCipher encrypts infrastructure states at rest with a key per organization
+type StateCipher interface {
+	// Encrypt returns state encrypted with the organization's key
+	Encrypt(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error)
+	// Decrypt returns the plaintext of encrypted state. States stored in plaintext are returned as they are
+	Decrypt(state map[string]interface{}) (map[string]interface{}, error)
+	// Rewrap re-encrypts state with the latest version of the organization's key
+	Rewrap(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error)
+	// Rotate creates a new version of the organization's key. Earlier versions still decrypt until states are rewrapped
+	Rotate(org eve.Organization) error
+}
+
+var (
+	cipherMu      sync.RWMutex
+	defaultCipher StateCipher
+
+	configuredCipherOnce sync.Once
+	configuredCipher     StateCipher
+)
+
+// SetStateCipher replaces the cipher of infrastructure states
+func SetStateCipher(cipher StateCipher) {
+	cipherMu.Lock()
+	defer cipherMu.Unlock()
+	defaultCipher = cipher
+}
+
+// DefaultStateCipher returns the cipher set by SetStateCipher, or the cipher selected by EVE_STATE_TRANSIT_MOUNT
+func DefaultStateCipher() StateCipher {
+	cipherMu.RLock()
+	cipher := defaultCipher
+	cipherMu.RUnlock()
+	if cipher != nil {
+		return cipher
+	}
+	configuredCipherOnce.Do(func() {
+		configuredCipher = NewStateCipher(config.NewStateEncryptionConfig())
+	})
+	return configuredCipher
+}
+
+// NewStateCipher returns the vault transit cipher of encryptionConfig, or a cipher keeping states in plaintext
+// when transit mount isn't configured
+func NewStateCipher(encryptionConfig *config.StateEncryptionConfig) StateCipher {
+	if encryptionConfig.TransitMount == "" {
+		return plainStateCipher{}
+	}
+	return &TransitStateCipher{Mount: encryptionConfig.TransitMount, KeyPrefix: encryptionConfig.KeyPrefix}
+}
+
+// TransitStateCipher encrypts states with vault transit keys named KeyPrefix + organization
+type TransitStateCipher struct {
+	Mount     string
+	KeyPrefix string
+}
+
+// KeyName returns the transit key of the organization
+func (c *TransitStateCipher) KeyName(org eve.Organization) string {
+	if org == "" {
+		org = DEFAULT_KEY_ORGANIZATION
+	}
+	return c.KeyPrefix + string(org)
+}
+
+func (c *TransitStateCipher) Encrypt(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error) {
+	if len(state) == 0 || eve.IsEncryptedState(state) {
+		return state, nil
+	}
+	plaintext, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	key := c.KeyName(org)
+	ciphertext, err := vault.TransitEncrypt(c.Mount, key, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encrypt state with key %s: %v", key, err)
+	}
+	return encryptedState(state, key, ciphertext), nil
+}
+
+func (c *TransitStateCipher) Decrypt(state map[string]interface{}) (map[string]interface{}, error) {
+	if !eve.IsEncryptedState(state) {
+		return state, nil
+	}
+	key, _ := state[eve.STATE_KEY].(string)
+	plaintext, err := vault.TransitDecrypt(c.Mount, key, state[eve.STATE_CIPHERTEXT].(string))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt state with key %s: %v", key, err)
+	}
+	var decrypted map[string]interface{}
+	if err := json.Unmarshal(plaintext, &decrypted); err != nil {
+		return nil, err
+	}
+	return decrypted, nil
+}
+
+// Rewrap encrypts plaintext states, and decrypts states encrypted with another organization's key to encrypt them again
+func (c *TransitStateCipher) Rewrap(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error) {
+	if !eve.IsEncryptedState(state) {
+		return c.Encrypt(org, state)
+	}
+	key := c.KeyName(org)
+	if state[eve.STATE_KEY] != key {
+		decrypted, err := c.Decrypt(state)
+		if err != nil {
+			return nil, err
+		}
+		return c.Encrypt(org, decrypted)
+	}
+	ciphertext, err := vault.TransitRewrap(c.Mount, key, state[eve.STATE_CIPHERTEXT].(string))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to rewrap state with key %s: %v", key, err)
+	}
+	return encryptedState(state, key, ciphertext), nil
+}
+
+func (c *TransitStateCipher) Rotate(org eve.Organization) error {
+	return vault.TransitRotate(c.Mount, c.KeyName(org))
+}
+
+// encryptedState returns the stored form of state, keeping its serial and lineage in plaintext
+func encryptedState(state map[string]interface{}, key string, ciphertext string) map[string]interface{} {
+	encrypted := map[string]interface{}{
+		eve.STATE_KEY:        key,
+		eve.STATE_CIPHERTEXT: ciphertext,
+	}
+	for _, field := range []string{"serial", "lineage"} {
+		if value, ok := state[field]; ok {
+			encrypted[field] = value
+		}
+	}
+	return encrypted
+}
+
+// plainStateCipher keeps states in plaintext while state encryption isn't configured
+type plainStateCipher struct{}
+
+func (plainStateCipher) Encrypt(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error) {
+	return state, nil
+}
+
+func (plainStateCipher) Decrypt(state map[string]interface{}) (map[string]interface{}, error) {
+	if eve.IsEncryptedState(state) {
+		return nil, fmt.Errorf("State is encrypted with key %v, but EVE_STATE_TRANSIT_MOUNT isn't set", state[eve.STATE_KEY])
+	}
+	return state, nil
+}
+
+func (plainStateCipher) Rewrap(org eve.Organization, state map[string]interface{}) (map[string]interface{}, error) {
+	return nil, fmt.Errorf("State encryption is disabled, set EVE_STATE_TRANSIT_MOUNT to rewrap states")
+}
+
+func (plainStateCipher) Rotate(org eve.Organization) error {
+	return fmt.Errorf("State encryption is disabled, set EVE_STATE_TRANSIT_MOUNT to rotate state keys")
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/concur/eve"
+	"github.com/concur/eve/pkg/config"
+	"github.com/concur/eve/service/boltdb"
+	"github.com/concur/eve/service/rethinkdb"
+)
+
+var (
+	storeMu      sync.RWMutex
+	defaultStore eve.Store
+
+	configuredOnce  sync.Once
+	configuredStore eve.Store
+)
+
+// SetDefaultStore replaces the store used by services created without an explicit store
+func SetDefaultStore(store eve.Store) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	defaultStore = store
+}
+
+// DefaultStore returns the store set by SetDefaultStore, or the store selected by EVE_STORE
+func DefaultStore() eve.Store {
+	storeMu.RLock()
+	store := defaultStore
+	storeMu.RUnlock()
+	if store != nil {
+		return store
+	}
+	if store = ConfiguredStore(); store != nil {
+		return store
+	}
+	return rethinkdb.DefaultSession()
+}
+
+// ConfiguredStore opens the embedded store selected by EVE_STORE once, and returns nil when rethinkdb is selected
+func ConfiguredStore() eve.Store {
+	configuredOnce.Do(func() {
+		storeConfig, err := config.NewStoreConfig()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if storeConfig.Type == config.STORE_BOLT {
+			store, err := boltdb.NewStore(storeConfig.Path)
+			if err != nil {
+				log.Fatalf("Failed to open bolt store %s: %v", storeConfig.Path, err)
+			}
+			configuredStore = store
+		}
+	})
+	return configuredStore
+}
+
+// resolveStore returns the injected store, or the default store which is resolved on every call
+// to keep the default rethinkdb session's reconnection
+func resolveStore(store eve.Store) eve.Store {
+	if store != nil {
+		return store
+	}
+	return DefaultStore()
+}
@@ -0,0 +1,199 @@
+package eve
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// StateVersion is one write of an infrastructure's terraform state. Every write is kept,
+// so an infrastructure can be rolled back to any earlier state.
+type StateVersion struct {
+	Id                 string                 `json:"id"`
+	InfrastructureName string                 `json:"infrastructureName"`
+	Serial             int64                  `json:"serial"`               // terraform state serial
+	Lineage            string                 `json:"lineage"`              // terraform state lineage
+	Writer             UserId                 `json:"writer"`               // user who wrote the state
+	RollbackOf         string                 `json:"rollbackOf,omitempty"` // id of the version restored by a rollback
+	CreatedAt          time.Time              `json:"createdAt"`            // write time
+	State              map[string]interface{} `json:"state,omitempty"`
+}
+
+// NewStateVersion returns the version of state written by writer to the infrastructure
+func NewStateVersion(infraName string, state map[string]interface{}, writer UserId) *StateVersion {
+	serial, lineage := StateSerial(state)
+	return &StateVersion{
+		InfrastructureName: infraName,
+		Serial:             serial,
+		Lineage:            lineage,
+		Writer:             writer,
+		State:              state,
+	}
+}
+
+// StateSerial returns terraform state's serial and lineage, which are zero values when state doesn't have them
+func StateSerial(state map[string]interface{}) (int64, string) {
+	var serial int64
+	switch value := state["serial"].(type) {
+	case float64:
+		serial = int64(value)
+	case int64:
+		serial = value
+	case int:
+		serial = int64(value)
+	case json.Number:
+		serial, _ = value.Int64()
+	}
+	lineage, _ := state["lineage"].(string)
+	return serial, lineage
+}
+
+// StateLock is terraform's lock of an infrastructure's state. Its JSON follows terraform's LockInfo,
+// so terraform can report the holder of the lock
+type StateLock struct {
+	ID                 string    `json:"ID"`        // lock id generated by terraform
+	Operation          string    `json:"Operation"` // terraform operation holding the lock, e.g. OperationTypeApply
+	Info               string    `json:"Info"`
+	Who                string    `json:"Who"` // user@hostname of terraform process
+	Version            string    `json:"Version"`
+	Path               string    `json:"Path"`
+	Created            time.Time `json:"Created"` // time the lock was acquired
+	InfrastructureName string    `json:"InfrastructureName"`
+	Holder             UserId    `json:"Holder"` // eve user who acquired the lock
+}
+
+// StateLockError is returned when infrastructure's state is locked by another lock
+type StateLockError struct {
+	Lock *StateLock
+}
+
+func (e *StateLockError) Error() string {
+	return fmt.Sprintf("State of infrastructure %s is locked by %s (%s) for %s since %s", e.Lock.InfrastructureName,
+		e.Lock.Holder, e.Lock.Who, e.Lock.Operation, e.Lock.Created.Format(time.RFC3339))
+}
+
+// StaleStateError is returned when a state write has a lower serial or a different lineage than the stored state
+type StaleStateError struct {
+	Name          string
+	Serial        int64
+	Lineage       string
+	StoredSerial  int64
+	StoredLineage string
+}
+
+func (e *StaleStateError) Error() string {
+	if e.Lineage != e.StoredLineage {
+		return fmt.Sprintf("State lineage %q of infrastructure %s doesn't match stored lineage %q", e.Lineage, e.Name, e.StoredLineage)
+	}
+	return fmt.Sprintf("State serial %d of infrastructure %s is lower than stored serial %d", e.Serial, e.Name, e.StoredSerial)
+}
+
+// CheckStateWrite returns *StaleStateError when state would overwrite stored state of a newer serial or another lineage
+func CheckStateWrite(name string, stored map[string]interface{}, state map[string]interface{}) error {
+	if len(stored) == 0 {
+		return nil
+	}
+	storedSerial, storedLineage := StateSerial(stored)
+	serial, lineage := StateSerial(state)
+	if (storedLineage != "" && lineage != storedLineage) || serial < storedSerial {
+		return &StaleStateError{Name: name, Serial: serial, Lineage: lineage, StoredSerial: storedSerial, StoredLineage: storedLineage}
+	}
+	return nil
+}
+
+// StateCondition conditions a write of infrastructure's current state. Stores evaluate it in the same
+// write as the state, so two states written concurrently cannot both pass it, and a lock taken
+// concurrently cannot be bypassed
+type StateCondition struct {
+	// LockId is the lock the writer holds. The write is rejected while the state is locked by another lock
+	LockId string
+	// RejectStale rejects state with a lower serial or another lineage than the stored state
+	RejectStale bool
+}
+
+// Check returns the error which the condition rejects state with, given infrastructure's lock and stored state.
+// A nil condition accepts every state
+func (c *StateCondition) Check(name string, lock *StateLock, stored map[string]interface{}, state map[string]interface{}) error {
+	if c == nil {
+		return nil
+	}
+	if lock != nil && lock.ID != c.LockId {
+		return &StateLockError{Lock: lock}
+	}
+	if c.RejectStale {
+		return CheckStateWrite(name, stored, state)
+	}
+	return nil
+}
+
+const (
+	STATE_KEY        = "eve_key"        // vault transit key which encrypted the state
+	STATE_CIPHERTEXT = "eve_ciphertext" // vault transit ciphertext of the whole terraform state
+)
+
+// IsEncryptedState reports whether state is stored encrypted. Encrypted states keep terraform's serial
+// and lineage readable, so state writes can be checked without decrypting them
+func IsEncryptedState(state map[string]interface{}) bool {
+	_, ok := state[STATE_CIPHERTEXT].(string)
+	return ok
+}
+
+// MASKED_OUTPUT replaces values of sensitive outputs for users without execute permission
+const MASKED_OUTPUT = "<sensitive>"
+
+// StateOutput is an output of an infrastructure's terraform state
+type StateOutput struct {
+	Value     interface{} `json:"value"`
+	Type      interface{} `json:"type,omitempty"` // e.g. "string" in legacy states, or a type expression like ["list","string"]
+	Sensitive bool        `json:"sensitive"`
+}
+
+// StateOutputs returns terraform state's outputs keyed by name. It reads root and module outputs of legacy
+// states' modules, and outputs of version 4 states. Module outputs are keyed by module path, e.g. module.vpc.id
+func StateOutputs(state map[string]interface{}) map[string]StateOutput {
+	outputs := map[string]StateOutput{}
+	modules, _ := state["modules"].([]interface{})
+	for _, m := range modules {
+		module, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		prefix := modulePrefix(module["path"])
+		moduleOutputs, _ := module["outputs"].(map[string]interface{})
+		for key, output := range moduleOutputs {
+			outputs[prefix+key] = stateOutput(output)
+		}
+	}
+	// Version 4 states only keep root module outputs
+	rootOutputs, _ := state["outputs"].(map[string]interface{})
+	for key, output := range rootOutputs {
+		outputs[key] = stateOutput(output)
+	}
+	return outputs
+}
+
+// modulePrefix turns a legacy module path like ["root","vpc"] into "module.vpc."
+func modulePrefix(path interface{}) string {
+	names, _ := path.([]interface{})
+	prefix := ""
+	for i, name := range names {
+		if i == 0 && name == "root" {
+			continue
+		}
+		prefix += fmt.Sprintf("module.%v.", name)
+	}
+	return prefix
+}
+
+// stateOutput reads an output, which is a bare value in states written before terraform 0.7
+func stateOutput(output interface{}) StateOutput {
+	fields, ok := output.(map[string]interface{})
+	if !ok {
+		return StateOutput{Value: output, Type: "string"}
+	}
+	if _, ok := fields["value"]; !ok {
+		return StateOutput{Value: output}
+	}
+	sensitive, _ := fields["sensitive"].(bool)
+	return StateOutput{Value: fields["value"], Type: fields["type"], Sensitive: sensitive}
+}
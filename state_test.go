@@ -0,0 +1,37 @@
+package eve_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/concur/eve"
+)
+
+func TestStateOutputs(t *testing.T) {
+	var legacy, v4 map[string]interface{}
+	json.Unmarshal([]byte(`{"version": 3, "modules": [
+		{"path": ["root"], "outputs": {
+			"kubernetes_api_dns": {"sensitive": false, "type": "string", "value": "k8s.example.com"},
+			"admin_password": {"sensitive": true, "type": "string", "value": "secret"}}},
+		{"path": ["root", "vpc"], "outputs": {"id": {"sensitive": false, "type": "string", "value": "vpc-1"}}}]}`), &legacy)
+	json.Unmarshal([]byte(`{"version": 4, "outputs": {
+		"subnets": {"value": ["a", "b"], "type": ["list", "string"]},
+		"admin_password": {"value": "secret", "type": "string", "sensitive": true}}}`), &v4)
+
+	outputs := eve.StateOutputs(legacy)
+	if len(outputs) != 3 || outputs["kubernetes_api_dns"].Value != "k8s.example.com" || outputs["module.vpc.id"].Value != "vpc-1" {
+		t.Errorf("Unexpected legacy outputs: %v", outputs)
+	}
+	if !outputs["admin_password"].Sensitive {
+		t.Errorf("Legacy sensitive output should be marked sensitive")
+	}
+
+	outputs = eve.StateOutputs(v4)
+	if len(outputs) != 2 || len(outputs["subnets"].Value.([]interface{})) != 2 || !outputs["admin_password"].Sensitive {
+		t.Errorf("Unexpected v4 outputs: %v", outputs)
+	}
+
+	if outputs := eve.StateOutputs(nil); len(outputs) != 0 {
+		t.Errorf("Empty state should have no outputs, got %v", outputs)
+	}
+}
@@ -0,0 +1,141 @@
+package eve
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var statusNames = map[Status]string{
+	DEFAULT:   "default",
+	VALIDATED: "validated",
+	RUNNING:   "running",
+	DEPLOYED:  "deployed",
+	DESTROYED: "destroyed",
+	OBSOLETED: "obsoleted",
+	FAILED:    "failed",
+}
+
+// NumericStatusJSON makes Status marshal into its legacy number in JSON, e.g. 2 instead of "validated".
+// It keeps clients which compare raw numbers working.
+var NumericStatusJSON = false
+
+// Statuses returns all lifecycle statuses in lifecycle order
+func Statuses() []Status {
+	return []Status{DEFAULT, VALIDATED, RUNNING, DEPLOYED, DESTROYED, OBSOLETED, FAILED}
+}
+
+// ParseStatus parses status from its name, e.g. "validated", or its number, e.g. "2"
+func ParseStatus(text string) (Status, error) {
+	for status, name := range statusNames {
+		if strings.EqualFold(name, text) {
+			return status, nil
+		}
+	}
+	if n, err := strconv.Atoi(text); err == nil {
+		if _, ok := statusNames[Status(n)]; ok {
+			return Status(n), nil
+		}
+	}
+	return DEFAULT, fmt.Errorf("Invalid status %q", text)
+}
+
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// MarshalText encodes status into its name
+func (s Status) MarshalText() ([]byte, error) {
+	if _, ok := statusNames[s]; !ok {
+		return nil, fmt.Errorf("Invalid status %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes status from its name or number
+func (s *Status) UnmarshalText(text []byte) error {
+	status, err := ParseStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+// MarshalJSON encodes status into its name, or its number when NumericStatusJSON is set
+func (s Status) MarshalJSON() ([]byte, error) {
+	if NumericStatusJSON {
+		return []byte(strconv.Itoa(int(s))), nil
+	}
+	text, err := s.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON decodes status from either a JSON string name or a JSON number
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		return s.UnmarshalText([]byte(name))
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("Invalid status %s", data)
+	}
+	return s.UnmarshalText([]byte(strconv.Itoa(n)))
+}
+
+// InfrastructureTransitions is infrastructure lifecycle's transition table. Key is the current
+// status and value is the statuses which infrastructure can move to from it.
+//
+//	VALIDATED -> RUNNING -> DEPLOYED | FAILED      (create)
+//	DEPLOYED  -> RUNNING -> DESTROYED | FAILED     (delete)
+//	FAILED | DESTROYED -> VALIDATED                (re-create)
+var InfrastructureTransitions = map[Status][]Status{
+	VALIDATED: {RUNNING, FAILED},
+	RUNNING:   {DEPLOYED, DESTROYED, FAILED},
+	DEPLOYED:  {RUNNING},
+	FAILED:    {VALIDATED, RUNNING},
+	DESTROYED: {VALIDATED},
+}
+
+// CanTransitionTo checks whether infrastructure can move from status s to status to
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range InfrastructureTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionSources returns the statuses from which infrastructure can move to status to
+func TransitionSources(to Status) []Status {
+	var sources []Status
+	for from := range InfrastructureTransitions {
+		if from.CanTransitionTo(to) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
+
+// TransitionError is returned when an infrastructure status update violates InfrastructureTransitions
+type TransitionError struct {
+	Name string
+	From Status
+	To   Status
+}
+
+func (e *TransitionError) Error() string {
+	if e.From == DEFAULT {
+		return fmt.Sprintf("Infrastructure %s cannot move to status %s from its current status", e.Name, e.To)
+	}
+	return fmt.Sprintf("Infrastructure %s cannot move from status %s to %s", e.Name, e.From, e.To)
+}
@@ -0,0 +1,80 @@
+package eve_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/concur/eve"
+)
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	cases := []struct {
+		from, to eve.Status
+		allowed  bool
+	}{
+		{eve.VALIDATED, eve.RUNNING, true},
+		{eve.RUNNING, eve.DEPLOYED, true},
+		{eve.RUNNING, eve.FAILED, true},
+		{eve.DEPLOYED, eve.RUNNING, true},
+		{eve.RUNNING, eve.DESTROYED, true},
+		{eve.DESTROYED, eve.VALIDATED, true},
+		{eve.FAILED, eve.VALIDATED, true},
+		{eve.RUNNING, eve.RUNNING, false},
+		{eve.VALIDATED, eve.DEPLOYED, false},
+		{eve.DEPLOYED, eve.DESTROYED, false},
+		{eve.DESTROYED, eve.RUNNING, false},
+		{eve.DEPLOYED, eve.VALIDATED, false},
+	}
+	for _, c := range cases {
+		if allowed := c.from.CanTransitionTo(c.to); allowed != c.allowed {
+			t.Errorf("Transition from %s to %s should be %v, got %v", c.from, c.to, c.allowed, allowed)
+		}
+	}
+}
+
+func TestTransitionSources(t *testing.T) {
+	sources := map[eve.Status]bool{}
+	for _, s := range eve.TransitionSources(eve.RUNNING) {
+		sources[s] = true
+	}
+	if len(sources) != 3 || !sources[eve.VALIDATED] || !sources[eve.DEPLOYED] || !sources[eve.FAILED] {
+		t.Errorf("Unexpected sources of RUNNING: %v", sources)
+	}
+	if len(eve.TransitionSources(eve.OBSOLETED)) != 0 {
+		t.Errorf("Infrastructure should never move to OBSOLETED")
+	}
+}
+
+func TestStatus_JSON(t *testing.T) {
+	infra := eve.Infrastructure{Name: "dev", Status: eve.DEPLOYED}
+	data, err := json.Marshal(infra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"status":"deployed"`) {
+		t.Errorf("Status should be marshalled into its name: %s", data)
+	}
+
+	for _, input := range []string{`{"status":"running"}`, `{"status":4}`, `{"status":"4"}`} {
+		var decoded eve.Infrastructure
+		if err := json.Unmarshal([]byte(input), &decoded); err != nil {
+			t.Fatalf("Unexpected error decoding %s: %v", input, err)
+		}
+		if decoded.Status != eve.RUNNING {
+			t.Errorf("Expected running status from %s, got %s", input, decoded.Status)
+		}
+	}
+
+	var decoded eve.Infrastructure
+	if err := json.Unmarshal([]byte(`{"status":"unknown"}`), &decoded); err == nil {
+		t.Errorf("Unknown status name should be rejected")
+	}
+
+	eve.NumericStatusJSON = true
+	defer func() { eve.NumericStatusJSON = false }()
+	data, _ = json.Marshal(infra)
+	if !strings.Contains(string(data), `"status":8`) {
+		t.Errorf("Status should be marshalled into its number in compatibility mode: %s", data)
+	}
+}
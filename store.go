@@ -0,0 +1,117 @@
+package eve
+
+import "github.com/pborman/uuid"
+
+// rethinkNamespace is the namespace of rethinkdb's r.UUID(name), so records keep the same id in every store
+var rethinkNamespace = uuid.Parse("91461c99-f89d-49d2-af96-d8e2e14e9b58")
+
+// NameId returns the id of the record with the unique name, the same as rethinkdb's r.UUID(name)
+func NameId(name string) string {
+	return uuid.NewSHA1(rethinkNamespace, []byte(name)).String()
+}
+
+// Store persists eve resources. Services depend on it instead of a concrete database,
+// so that the database can be swapped and services can be tested without it.
+type Store interface {
+	QuoinStore
+	QuoinArchiveStore
+	InfrastructureStore
+	StateVersionStore
+	StateLockStore
+	ProviderStore
+	AuditStore
+}
+
+// QuoinStore persists quoins keyed by quoin name
+type QuoinStore interface {
+	// InsertQuoin stores a new quoin and refreshes quoin with the stored record
+	InsertQuoin(quoin *Quoin) error
+	UpdateQuoin(name string, quoin *Quoin) error
+	UpdateQuoinAccess(name string, group Group, mode PolicyMode) error
+	DeleteQuoinAccess(name string, group Group) error
+	// UpdateQuoinOwner transfers ownership of the quoin and all of its archives
+	UpdateQuoinOwner(name string, from UserId, to UserId) error
+	// GetQuoinByName returns nil when the quoin doesn't exist
+	GetQuoinByName(name string) (*Quoin, error)
+	// ListQuoins returns quoins matching options ordered by name
+	ListQuoins(options ListOptions) ([]Quoin, error)
+}
+
+// QuoinArchiveStore persists quoin archives keyed by generated archive id
+type QuoinArchiveStore interface {
+	// InsertQuoinArchive stores archive, sets archive's id and points its quoin's archive uri to it
+	InsertQuoinArchive(archive *QuoinArchive) error
+	// GetQuoinArchiveById returns nil when the archive doesn't exist
+	GetQuoinArchiveById(id string) (*QuoinArchive, error)
+	// GetQuoinArchivesByQuoin returns the quoin's archives without their modules, newest first
+	GetQuoinArchivesByQuoin(quoinName string) ([]QuoinArchive, error)
+	// DeleteQuoinArchive removes the archive without checking its references
+	DeleteQuoinArchive(id string) error
+}
+
+// InfrastructureStore persists infrastructures keyed by infrastructure name
+type InfrastructureStore interface {
+	InsertInfrastructure(infra *Infrastructure) error
+	// UpdateInfrastructureState replaces infrastructure's current state when condition accepts it, and returns
+	// the condition's error otherwise. A nil condition accepts every state
+	UpdateInfrastructureState(name string, state map[string]interface{}, condition *StateCondition) error
+	// UpdateInfrastructureStatus returns *TransitionError when infrastructure cannot move to status
+	UpdateInfrastructureStatus(name string, status Status) error
+	// UpdateInfrastructureError moves infrastructure to FAILED with the error, or clears the error when it's nil
+	UpdateInfrastructureError(name string, infraError error) error
+	UpdateInfrastructureAccess(name string, group Group, mode PolicyMode) error
+	DeleteInfrastructureAccess(name string, group Group) error
+	UpdateInfrastructureOwner(name string, from UserId, to UserId) error
+	// GetInfrastructureByName returns nil when the infrastructure doesn't exist
+	GetInfrastructureByName(name string) (*Infrastructure, error)
+	// GetInfrastructuresByQuoin returns infrastructures using the quoin which aren't destroyed, including failed ones,
+	// without their states
+	GetInfrastructuresByQuoin(name string) ([]Infrastructure, error)
+	// ListInfrastructures returns infrastructures matching options ordered by name, without their states
+	ListInfrastructures(options ListOptions) ([]Infrastructure, error)
+}
+
+// StateVersionStore persists every write of infrastructures' terraform states keyed by generated version id
+type StateVersionStore interface {
+	// InsertStateVersion makes version's state its infrastructure's current state like UpdateInfrastructureState,
+	// then stores version and sets its id. Nothing is stored when the infrastructure doesn't exist or condition
+	// rejects the state
+	InsertStateVersion(version *StateVersion, condition *StateCondition) error
+	// GetStateVersionById returns nil when the version doesn't exist
+	GetStateVersionById(id string) (*StateVersion, error)
+	// GetStateVersionsByInfrastructure returns the infrastructure's versions without their states, newest first
+	GetStateVersionsByInfrastructure(name string) ([]StateVersion, error)
+	// UpdateStateVersionState replaces the version's stored state, e.g. with the state encrypted by another key
+	UpdateStateVersionState(id string, state map[string]interface{}) error
+}
+
+// StateLockStore persists terraform's locks of infrastructures' states keyed by infrastructure name
+type StateLockStore interface {
+	// InsertStateLock stores lock unless the infrastructure's state is already locked, and returns the existing lock then
+	InsertStateLock(lock *StateLock) (*StateLock, error)
+	// GetStateLock returns nil when the infrastructure's state isn't locked
+	GetStateLock(name string) (*StateLock, error)
+	// DeleteStateLock removes the lock when its ID is id, or any lock when id is empty
+	DeleteStateLock(name string, id string) error
+}
+
+// ProviderStore persists providers keyed by provider name
+type ProviderStore interface {
+	InsertProvider(provider *Provider) error
+	// UpdateProvider replaces provider's schema. Provider's authorization is kept as it is
+	UpdateProvider(name string, provider *Provider) error
+	UpdateProviderAccess(name string, group Group, mode PolicyMode) error
+	DeleteProviderAccess(name string, group Group) error
+	DeleteProvider(name string) error
+	// GetProviderByName returns nil when the provider doesn't exist
+	GetProviderByName(name string) (*Provider, error)
+	// GetProviders returns all providers ordered by name
+	GetProviders() ([]Provider, error)
+	// CountInfrastructuresByProvider counts not yet destroyed infrastructures using the provider
+	CountInfrastructuresByProvider(name string) (int, error)
+}
+
+// AuditStore persists audit records
+type AuditStore interface {
+	InsertAuditRecord(record *AuditRecord) error
+}